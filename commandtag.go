@@ -0,0 +1,58 @@
+// Copyright 2026 The go-pgsql Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pgsql
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CommandTag describes a completed SQL command, as reported by the server,
+// e.g. "INSERT 0 5", "SELECT 3" or "CREATE TABLE".
+type CommandTag struct {
+	Command      string // Command name, like INSERT or CREATE TABLE
+	RowsAffected int64  // Number of rows affected or returned, 0 if not reported
+	InsertOID    uint32 // OID of the inserted row for single row INSERTs into tables with OIDs, otherwise 0
+	text         string
+}
+
+func parseCommandTag(text string) CommandTag {
+	tag := CommandTag{text: text}
+
+	words := strings.Fields(text)
+
+	// Command names don't contain numbers, so all trailing numbers belong
+	// to the counts.
+	var numbers []string
+	for len(words) > 1 {
+		word := words[len(words)-1]
+		if _, err := strconv.ParseUint(word, 10, 64); err != nil {
+			break
+		}
+
+		numbers = append([]string{word}, numbers...)
+		words = words[:len(words)-1]
+	}
+
+	tag.Command = strings.Join(words, " ")
+
+	switch len(numbers) {
+	case 1:
+		tag.RowsAffected, _ = strconv.ParseInt(numbers[0], 10, 64)
+
+	case 2:
+		// INSERT oid rows
+		oid, _ := strconv.ParseUint(numbers[0], 10, 32)
+		tag.InsertOID = uint32(oid)
+		tag.RowsAffected, _ = strconv.ParseInt(numbers[1], 10, 64)
+	}
+
+	return tag
+}
+
+// String returns the command tag as sent by the server.
+func (tag CommandTag) String() string {
+	return tag.text
+}
@@ -9,16 +9,21 @@ package pgsql
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 )
 
+var quoteRegExp = regexp.MustCompile("['][^']*[']")
+
 // LogLevel is used to control what is written to the log.
 type LogLevel int
 
@@ -45,13 +50,50 @@ const (
 	LogVerbose
 )
 
-type connParams struct {
-	Host           string
-	Port           int
-	User           string
-	Password       string
-	Database       string
-	TimeoutSeconds int
+// ConnConfig contains the settings used to establish a database connection.
+//
+// See Connect for a description of the settings. Unlike with Connect, PG*
+// environment variables and the password file are not consulted.
+type ConnConfig struct {
+	Host                   string
+	Port                   int
+	User                   string
+	Password               string
+	Database               string
+	TimeoutSeconds         int
+	StatementCacheCapacity int  // 0 disables the cache
+	DiscardAllOnReset      bool // Run DISCARD ALL when database/sql reuses the connection
+
+	// OnNotice is called for each notice or warning received from the server,
+	// see *Conn.OnNotice.
+	OnNotice func(notice *Notice)
+}
+
+func (config *ConnConfig) useEnvironment() {
+	if env := os.Getenv("PGHOST"); env != "" {
+		config.Host = env
+	}
+	if env := os.Getenv("PGPORT"); env != "" {
+		config.Port, _ = strconv.Atoi(env)
+	}
+	if env := os.Getenv("PGDATABASE"); env != "" {
+		config.Database = env
+	}
+	if env := os.Getenv("PGUSER"); env != "" {
+		config.User = env
+	}
+}
+
+func (config *ConnConfig) useDefaults() {
+	if config.Host == "" {
+		config.Host = "localhost"
+	}
+	if config.Port == 0 {
+		config.Port = 5432
+	}
+	if config.Database == "" {
+		config.Database = config.User
+	}
 }
 
 // ConnStatus represents the status of a connection.
@@ -88,6 +130,9 @@ type IsolationLevel int
 const (
 	ReadCommittedIsolation IsolationLevel = iota
 	SerializableIsolation
+	RepeatableReadIsolation
+	ReadUncommittedIsolation
+	DefaultIsolation // The default_transaction_isolation of the session
 )
 
 func (il IsolationLevel) String() string {
@@ -97,11 +142,114 @@ func (il IsolationLevel) String() string {
 
 	case SerializableIsolation:
 		return "Serializable"
+
+	case RepeatableReadIsolation:
+		return "Repeatable Read"
+
+	case ReadUncommittedIsolation:
+		return "Read Uncommitted"
+
+	case DefaultIsolation:
+		return "Default"
 	}
 
 	return "Unknown"
 }
 
+// sql returns the isolation level as used in a BEGIN command, or an empty
+// string for DefaultIsolation.
+func (il IsolationLevel) sql() string {
+	switch il {
+	case ReadCommittedIsolation:
+		return "READ COMMITTED"
+
+	case SerializableIsolation:
+		return "SERIALIZABLE"
+
+	case RepeatableReadIsolation:
+		return "REPEATABLE READ"
+
+	case ReadUncommittedIsolation:
+		return "READ UNCOMMITTED"
+
+	case DefaultIsolation:
+		return ""
+	}
+
+	panic(fmt.Errorf("invalid isolation level: %d", il))
+}
+
+// TxAccessMode represents the access mode of a transaction.
+type TxAccessMode int
+
+const (
+	DefaultAccessMode TxAccessMode = iota // The default_transaction_read_only of the session
+	ReadWriteAccess
+	ReadOnlyAccess
+)
+
+func (m TxAccessMode) String() string {
+	switch m {
+	case DefaultAccessMode:
+		return "Default"
+
+	case ReadWriteAccess:
+		return "Read Write"
+
+	case ReadOnlyAccess:
+		return "Read Only"
+	}
+
+	return "Unknown"
+}
+
+// TxOptions contains the characteristics of a transaction.
+//
+// The zero value of IsolationLevel is ReadCommittedIsolation, so the zero
+// TxOptions starts a READ COMMITTED transaction. Use DefaultIsolation for the
+// default_transaction_isolation of the session.
+type TxOptions struct {
+	Isolation  IsolationLevel
+	AccessMode TxAccessMode
+
+	// Deferrable only has an effect for serializable read only
+	// transactions, which then wait until they can run without the
+	// overhead of serializable isolation and can't fail because of it.
+	Deferrable bool
+}
+
+// beginCommand returns the BEGIN command starting a transaction with opts.
+func (opts TxOptions) beginCommand() string {
+	var modes []string
+
+	if isolation := opts.Isolation.sql(); isolation != "" {
+		modes = append(modes, "ISOLATION LEVEL "+isolation)
+	}
+
+	switch opts.AccessMode {
+	case DefaultAccessMode:
+
+	case ReadWriteAccess:
+		modes = append(modes, "READ WRITE")
+
+	case ReadOnlyAccess:
+		modes = append(modes, "READ ONLY")
+
+	default:
+		panic(fmt.Errorf("invalid access mode: %d", opts.AccessMode))
+	}
+
+	if opts.Deferrable {
+		modes = append(modes, "DEFERRABLE")
+	}
+
+	if len(modes) == 0 {
+		return "BEGIN;"
+	}
+
+	return "BEGIN " + strings.Join(modes, ", ") + ";"
+}
+
 // TransactionStatus represents the transaction status of a connection.
 type TransactionStatus byte
 
@@ -129,10 +277,12 @@ func (s TransactionStatus) String() string {
 // Conn represents a PostgreSQL database connection.
 type Conn struct {
 	LogLevel                        LogLevel
+	StrictStructScan                bool // Columns without matching struct field cause an error
 	tcpConn                         net.Conn
 	reader                          *bufio.Reader
 	writer                          *bufio.Writer
-	params                          *connParams
+	params                          *ConnConfig
+	addr                            string
 	state                           state
 	backendPID                      int32
 	backendSecretKey                int32
@@ -141,6 +291,13 @@ type Conn struct {
 	nextStatementId                 uint64
 	nextPortalId                    uint64
 	nextSavepointId                 uint64
+	stmtCache                       *stmtCache
+	discardAllOnReset               bool
+	sessionGeneration               uint64     // Incremented by DISCARD ALL
+	flushes                         uint64     // Number of successful flushes, see badConnErr
+	activeCommand                   string     // Command being processed, for error positions
+	activeStmt                      *Statement // Statement being prepared or executed, if any
+	onNotice                        func(notice *Notice)
 	transactionStatus               TransactionStatus
 	dateFormat                      string
 	timeFormat                      string
@@ -155,7 +312,7 @@ func (conn *Conn) withRecover(funcName string, f func()) (err error) {
 
 	defer func() {
 		if x := recover(); x != nil {
-			err = conn.logAndConvertPanic(x)
+			err = conn.logAndConvertPanic(funcName, x)
 		}
 	}()
 
@@ -164,6 +321,17 @@ func (conn *Conn) withRecover(funcName string, f func()) (err error) {
 	return
 }
 
+// panicIfIOErr panics if err is not nil, after closing the connection, because
+// it is impossible to tell where in the protocol we are after an I/O error.
+func (conn *Conn) panicIfIOErr(err error) {
+	if err != nil {
+		conn.tcpConn.Close()
+		conn.state = disconnectedState{}
+
+		panic(err)
+	}
+}
+
 func parseParamsInUnquotedSubstring(s string, name2value map[string]string) (lastKeyword string) {
 	var words []string
 
@@ -194,7 +362,7 @@ func parseParamsInUnquotedSubstring(s string, name2value map[string]string) (las
 	return
 }
 
-func (conn *Conn) parseParams(s string) *connParams {
+func (conn *Conn) parseParams(s string) *ConnConfig {
 	name2value := make(map[string]string)
 
 	quoteIndexPairs := quoteRegExp.FindAllStringIndex(s, -1)
@@ -218,7 +386,7 @@ func (conn *Conn) parseParams(s string) *connParams {
 		parseParamsInUnquotedSubstring(s, name2value)
 	}
 
-	params := &connParams{}
+	params := &ConnConfig{}
 
 	params.Host = name2value["host"]
 	params.Port, _ = strconv.Atoi(name2value["port"])
@@ -229,6 +397,11 @@ func (conn *Conn) parseParams(s string) *connParams {
 		params.Password, _ = passwordfromfile(params.Host, params.Port, params.Database, params.User)
 	}
 	params.TimeoutSeconds, _ = strconv.Atoi(name2value["timeout"])
+	params.StatementCacheCapacity = defaultStatementCacheCapacity
+	if s, ok := name2value["statement_cache_capacity"]; ok {
+		params.StatementCacheCapacity, _ = strconv.Atoi(s)
+	}
+	params.DiscardAllOnReset, _ = strconv.ParseBool(name2value["discard_all_on_reset"])
 
 	if conn.LogLevel >= LogDebug {
 		buf := bytes.NewBuffer(nil)
@@ -256,81 +429,138 @@ func (conn *Conn) parseParams(s string) *connParams {
 //	dbname 		= Database name (default: same as user)
 //	user 		= User to connect as
 //	password	= Password for password based authentication methods
-//	timeout		= Timeout in seconds, 0 or not specified disables timeout (default: 0)
+//	timeout		= Timeout in seconds for establishing the connection,
+//			  0 or not specified disables timeout (default: 0)
+//	statement_cache_capacity = Number of prepared statements cached for reuse by
+//			Query and Execute, 0 disables the cache (default: 32)
+//	discard_all_on_reset = Whether to run DISCARD ALL when database/sql
+//			reuses the connection (default: false)
 func Connect(connStr string, logLevel LogLevel) (conn *Conn, err error) {
+	return connectContext(context.Background(), connStr, logLevel)
+}
+
+func connectContext(ctx context.Context, connStr string, logLevel LogLevel) (conn *Conn, err error) {
 	newConn := &Conn{}
 
 	newConn.LogLevel = logLevel
 	newConn.state = disconnectedState{}
 
-	if newConn.LogLevel >= LogDebug {
-		defer newConn.logExit(newConn.logEnter("Connect"))
-	}
+	err = newConn.withRecover("Connect", func() {
+		config := newConn.parseParams(connStr)
+		config.useEnvironment()
 
-	defer func() {
-		if x := recover(); x != nil {
-			err = newConn.logAndConvertPanic(x)
-		}
-	}()
+		newConn.connect(ctx, config)
+
+		conn = newConn
+	})
+
+	return
+}
 
-	params := newConn.parseParams(connStr)
-	newConn.params = params
+// ConnectConfig establishes a database connection using the settings in
+// config.
+func ConnectConfig(config *ConnConfig, logLevel LogLevel) (conn *Conn, err error) {
+	return connectConfigContext(context.Background(), config, logLevel)
+}
 
-	var env string // Reusable environment variable used to capture PG environment variables - PGHOST, PGPORT, PGDATABASE, PGUSER
+func connectConfigContext(ctx context.Context, config *ConnConfig, logLevel LogLevel) (conn *Conn, err error) {
+	newConn := &Conn{}
 
-	if params.Host == "" {
-		params.Host = "localhost"
-	}
-	env = os.Getenv("PGHOST")
-	if env != "" {
-		params.Host = env
-	}
-	if params.Port == 0 {
-		params.Port = 5432
-	}
-	env = os.Getenv("PGPORT")
-	if env != "" {
-		params.Port, _ = strconv.Atoi(env)
-	}
-	if params.Database == "" {
-		params.Database = params.User
-	}
-	env = os.Getenv("PGDATABASE")
-	if env != "" {
-		params.Database = env
-	}
-	env = os.Getenv("PGUSER")
-	if env != "" {
-		params.User = env
+	newConn.LogLevel = logLevel
+	newConn.state = disconnectedState{}
+
+	err = newConn.withRecover("ConnectConfig", func() {
+		c := *config
+
+		newConn.connect(ctx, &c)
+
+		conn = newConn
+	})
+
+	return
+}
+
+func (conn *Conn) connect(ctx context.Context, config *ConnConfig) {
+	config.useDefaults()
+
+	conn.params = config
+	conn.addr = net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+
+	if config.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(config.TimeoutSeconds)*time.Second)
+		defer cancel()
 	}
 
-	tcpConn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", params.Host, params.Port))
+	var dialer net.Dialer
+	tcpConn, err := dialer.DialContext(ctx, "tcp", conn.addr)
 	panicIfErr(err)
 
-	panicIfErr(tcpConn.SetDeadline(time.Unix(int64(params.TimeoutSeconds*1000*1000*1000), 0)))
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			tcpConn.Close()
+		}
+	}()
+
+	// The deadline only applies to the startup phase.
+	if deadline, ok := ctx.Deadline(); ok {
+		panicIfErr(tcpConn.SetDeadline(deadline))
+		defer tcpConn.SetDeadline(time.Time{})
+	}
 
-	newConn.tcpConn = tcpConn
+	conn.tcpConn = tcpConn
 
-	newConn.reader = bufio.NewReader(tcpConn)
-	newConn.writer = bufio.NewWriter(tcpConn)
+	conn.reader = bufio.NewReader(tcpConn)
+	conn.writer = bufio.NewWriter(tcpConn)
 
-	newConn.runtimeParameters = make(map[string]string)
+	conn.runtimeParameters = make(map[string]string)
 
-	newConn.onErrorDontRequireReadyForQuery = true
+	conn.stmtCache = newStmtCache(config.StatementCacheCapacity)
+	conn.discardAllOnReset = config.DiscardAllOnReset
+	conn.onNotice = config.OnNotice
+
+	conn.onErrorDontRequireReadyForQuery = true
 	defer func() {
-		newConn.onErrorDontRequireReadyForQuery = false
+		conn.onErrorDontRequireReadyForQuery = false
 	}()
 
-	newConn.writeStartup()
+	conn.writeStartup()
 
-	newConn.readBackendMessages(nil)
+	conn.readBackendMessages(nil)
 
-	newConn.state = readyState{}
-	newConn.params = nil
+	conn.state = readyState{}
+	conn.params = nil
 
-	newConn.transactionStatus = NotInTransaction
+	conn.transactionStatus = NotInTransaction
 
-	conn = newConn
+	succeeded = true
+}
+
+// Cancel requests the server to cancel the command the connection is
+// currently processing.
+//
+// Cancel may be called from another goroutine. The request is sent over a
+// separate connection, there is no guarantee that the server will act on it.
+// If the command is canceled, it fails with an *Error with code 57014.
+func (conn *Conn) Cancel() (err error) {
+	if conn.LogLevel >= LogDebug {
+		defer conn.logExit(conn.logEnter("*Conn.Cancel"))
+	}
+
+	tcpConn, err := net.Dial("tcp", conn.addr)
+	if err != nil {
+		return
+	}
+	defer tcpConn.Close()
+
+	var msg [16]byte
+	binary.BigEndian.PutUint32(msg[0:], 16)
+	binary.BigEndian.PutUint32(msg[4:], cancelRequestCode)
+	binary.BigEndian.PutUint32(msg[8:], uint32(conn.backendPID))
+	binary.BigEndian.PutUint32(msg[12:], uint32(conn.backendSecretKey))
+
+	_, err = tcpConn.Write(msg[:])
 
 	return
 }
@@ -339,8 +569,7 @@ func Connect(connStr string, logLevel LogLevel) (conn *Conn, err error) {
 func (conn *Conn) Close() (err error) {
 	return conn.withRecover("*Conn.Close", func() {
 		if conn.Status() == StatusDisconnected {
-			err = errors.New("connection already closed")
-			conn.logError(LogWarning, err)
+			conn.logError(LogWarning, ErrConnClosed)
 			return
 		}
 
@@ -357,11 +586,12 @@ func (conn *Conn) copyFrom(command string, r io.Reader) int64 {
 		defer conn.logExit(conn.logEnter("*Conn.copyFrom"))
 	}
 
+	conn.activeCommand, conn.activeStmt = command, nil
+
 	conn.writeQuery(command)
 	conn.readBackendMessages(nil)
 	if stateCode := conn.state.code(); stateCode != StatusCopy {
-		panic("wrong state, expected: StatusCopy, have: " + stateCode.String())
-		return 0
+		panic(fmt.Errorf("%w, expected: StatusCopy, have: %s", ErrInvalidState, stateCode))
 	}
 
 	// FIXME: magic number; wild guess without any reason.
@@ -398,7 +628,7 @@ func (conn *Conn) copyFrom(command string, r io.Reader) int64 {
 	conn.readBackendMessages(rs)
 	rs.close()
 
-	return rs.rowsAffected
+	return rs.commandTag.RowsAffected
 }
 
 // CopyFrom sends a `COPY table FROM STDIN` SQL command to the server and
@@ -482,7 +712,7 @@ func passwordfromfile(hostname string, port int, dbname string, username string)
 	return "", nil
 }
 
-func (conn *Conn) execute(command string, params ...*Parameter) int64 {
+func (conn *Conn) execute(command string, params ...*Parameter) CommandTag {
 	if conn.LogLevel >= LogDebug {
 		defer conn.logExit(conn.logEnter("*Conn.execute"))
 	}
@@ -490,17 +720,34 @@ func (conn *Conn) execute(command string, params ...*Parameter) int64 {
 	rs := conn.query(command, params...)
 	rs.close()
 
-	return rs.rowsAffected
+	return rs.commandTag
 }
 
 // Execute sends a SQL command to the server and returns the number
 // of rows affected.
 //
+// The args are either *Parameter values for named parameters or plain
+// values for $1, $2 ... placeholders, see Query.
+//
 // If the results of a query are needed, use the
 // Query method instead.
-func (conn *Conn) Execute(command string, params ...*Parameter) (rowsAffected int64, err error) {
+func (conn *Conn) Execute(command string, args ...interface{}) (rowsAffected int64, err error) {
 	err = conn.withRecover("*Conn.Execute", func() {
-		rowsAffected = conn.execute(command, params...)
+		rowsAffected = conn.execute(command, paramsFromArgs(args)...).RowsAffected
+	})
+
+	return
+}
+
+// ExecuteTag sends a SQL command to the server and returns its command tag.
+// If command consists of several commands, the tag of the last one is
+// returned.
+//
+// The args are either *Parameter values for named parameters or plain
+// values for $1, $2 ... placeholders, see Query.
+func (conn *Conn) ExecuteTag(command string, args ...interface{}) (tag CommandTag, err error) {
+	err = conn.withRecover("*Conn.ExecuteTag", func() {
+		tag = conn.execute(command, paramsFromArgs(args)...)
 	})
 
 	return
@@ -520,9 +767,13 @@ func (conn *Conn) prepare(command string, params ...*Parameter) *Statement {
 
 // Prepare returns a new prepared Statement, optimized to be executed multiple
 // times with different parameter values.
-func (conn *Conn) Prepare(command string, params ...*Parameter) (stmt *Statement, err error) {
+//
+// The args are either *Parameter values for named parameters or plain
+// values for $1, $2 ... placeholders, see Query. Parameters for placeholders
+// without a value are created automatically, see *Statement.ParamTypes.
+func (conn *Conn) Prepare(command string, args ...interface{}) (stmt *Statement, err error) {
 	err = conn.withRecover("*Conn.Prepare", func() {
-		stmt = conn.prepare(command, params...)
+		stmt = conn.prepare(command, paramsFromArgs(args)...)
 	})
 
 	return
@@ -533,7 +784,6 @@ func (conn *Conn) query(command string, params ...*Parameter) (rs *ResultSet) {
 		defer conn.logExit(conn.logEnter("*Conn.query"))
 	}
 
-	var stmt *Statement
 	if len(params) == 0 {
 		r := newResultSet(conn)
 
@@ -541,8 +791,14 @@ func (conn *Conn) query(command string, params ...*Parameter) (rs *ResultSet) {
 
 		rs = r
 	} else {
-		stmt = conn.prepare(command, params...)
-		defer stmt.close()
+		stmt, cached := conn.cachedStatement(command, params)
+		if !cached {
+			defer stmt.close()
+		}
+
+		if len(stmt.paramTypes) != len(params) {
+			panic(&ArgumentCountError{Have: len(params), Want: len(stmt.paramTypes)})
+		}
 
 		rs = stmt.query()
 	}
@@ -553,11 +809,24 @@ func (conn *Conn) query(command string, params ...*Parameter) (rs *ResultSet) {
 // Query sends a SQL query to the server and returns a
 // ResultSet for row-by-row retrieval of the results.
 //
+// The args are either all *Parameter values, referred to by name in command,
+// or all plain Go values for the $1, $2 ... placeholders in command:
+//
+//	rs, err := conn.Query("SELECT * FROM table1 WHERE id = @id;", idParam)
+//	rs, err := conn.Query("SELECT * FROM table1 WHERE id = $1;", 2)
+//
+// The types of plain values are inferred by the server from the command,
+// except for time.Time values, which are sent as TimestampTZ. Values
+// implementing driver.Valuer are replaced by their Value, nil values are sent
+// as NULL. Besides the basic Go types, []int64 and []string are sent as
+// arrays, time.Duration as interval, [16]byte as uuid and json.RawMessage,
+// net.IP and big.Rat as their text representation.
+//
 // The returned ResultSet must be closed before sending another
 // query or command to the server over the same connection.
-func (conn *Conn) Query(command string, params ...*Parameter) (rs *ResultSet, err error) {
+func (conn *Conn) Query(command string, args ...interface{}) (rs *ResultSet, err error) {
 	err = conn.withRecover("*Conn.Query", func() {
-		rs = conn.query(command, params...)
+		rs = conn.query(command, paramsFromArgs(args)...)
 	})
 
 	return
@@ -601,6 +870,16 @@ func (conn *Conn) Scan(command string, args ...interface{}) (fetched bool, err e
 	return
 }
 
+// OnNotice sets the function called for each notice or warning received from
+// the server, replacing the one set before or by ConnConfig.OnNotice. If f is
+// nil, notices are just logged at LogDebug.
+//
+// f is called while the response to a command is being read, so it must not
+// use the Conn.
+func (conn *Conn) OnNotice(f func(notice *Notice)) {
+	conn.onNotice = f
+}
+
 // Status returns the current connection status.
 func (conn *Conn) Status() ConnStatus {
 	return conn.state.code()
@@ -620,22 +899,28 @@ func (conn *Conn) TransactionStatus() TransactionStatus {
 // without calling f. In case of an active transaction without error,
 // WithTransaction just calls f.
 func (conn *Conn) WithTransaction(isolation IsolationLevel, f func() error) (err error) {
+	return conn.WithTransactionOptions(TxOptions{Isolation: isolation}, f)
+}
+
+// WithTransactionOptions is like WithTransaction, but starts the transaction
+// with the characteristics specified by opts.
+func (conn *Conn) WithTransactionOptions(opts TxOptions, f func() error) (err error) {
 	if conn.LogLevel >= LogDebug {
-		defer conn.logExit(conn.logEnter("*Conn.WithTransaction"))
+		defer conn.logExit(conn.logEnter("*Conn.WithTransactionOptions"))
 	}
 
 	oldStatus := conn.transactionStatus
 
 	if oldStatus == InFailedTransaction {
-		return conn.logAndConvertPanic("error in transaction")
+		return conn.logAndConvertPanic("*Conn.WithTransactionOptions", ErrTxFailed)
 	}
 
 	defer func() {
 		if x := recover(); x != nil {
-			err = conn.logAndConvertPanic(x)
+			err = conn.logAndConvertPanic("*Conn.WithTransactionOptions", x)
 		}
 		if err == nil && conn.transactionStatus == InFailedTransaction {
-			err = conn.logAndConvertPanic("error in transaction")
+			err = conn.logAndConvertPanic("*Conn.WithTransactionOptions", ErrTxFailed)
 		}
 		if err != nil && oldStatus == NotInTransaction {
 			conn.execute("ROLLBACK;")
@@ -643,14 +928,7 @@ func (conn *Conn) WithTransaction(isolation IsolationLevel, f func() error) (err
 	}()
 
 	if oldStatus == NotInTransaction {
-		var isol string
-		if isolation == SerializableIsolation {
-			isol = "SERIALIZABLE"
-		} else {
-			isol = "READ COMMITTED"
-		}
-		cmd := fmt.Sprintf("BEGIN; SET TRANSACTION ISOLATION LEVEL %s;", isol)
-		conn.execute(cmd)
+		conn.execute(opts.beginCommand())
 	}
 
 	panicIfErr(f())
@@ -678,7 +956,7 @@ func (conn *Conn) WithSavepoint(isolation IsolationLevel, f func() error) (err e
 
 	switch oldStatus {
 	case InFailedTransaction:
-		return conn.logAndConvertPanic("error in transaction")
+		return conn.logAndConvertPanic("*Conn.WithSavepoint", ErrTxFailed)
 
 	case NotInTransaction:
 		return conn.WithTransaction(isolation, f)
@@ -689,10 +967,10 @@ func (conn *Conn) WithSavepoint(isolation IsolationLevel, f func() error) (err e
 
 	defer func() {
 		if x := recover(); x != nil {
-			err = conn.logAndConvertPanic(x)
+			err = conn.logAndConvertPanic("*Conn.WithSavepoint", x)
 		}
 		if err == nil && conn.transactionStatus == InFailedTransaction {
-			err = conn.logAndConvertPanic("error in transaction")
+			err = conn.logAndConvertPanic("*Conn.WithSavepoint", ErrTxFailed)
 		}
 		if err != nil {
 			conn.execute(fmt.Sprintf("ROLLBACK TO %s;", savepointName))
@@ -706,6 +984,27 @@ func (conn *Conn) WithSavepoint(isolation IsolationLevel, f func() error) (err e
 	return
 }
 
+// discardAll resets the session state, including prepared statements, which
+// makes all Statements of the connection unusable.
+func (conn *Conn) discardAll() {
+	if conn.LogLevel >= LogDebug {
+		defer conn.logExit(conn.logEnter("*Conn.discardAll"))
+	}
+
+	conn.execute("DISCARD ALL;")
+
+	conn.sessionGeneration++
+
+	// No need to send Close packets, the statements are already gone.
+	conn.stmtCache.evict(0)
+}
+
+// standardConformingStrings returns if the server treats backslashes
+// literally in ordinary string constants.
+func (conn *Conn) standardConformingStrings() bool {
+	return conn.runtimeParameters["standard_conforming_strings"] != "off"
+}
+
 func (conn *Conn) updateTimeFormats() {
 	style := conn.runtimeParameters["DateStyle"]
 
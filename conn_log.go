@@ -6,7 +6,6 @@ package pgsql
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"log"
 	"runtime"
@@ -35,38 +34,40 @@ func (conn *Conn) logExit(funcName string) {
 	conn.log(LogDebug, "exiting: ", "pgsql."+funcName)
 }
 
-func (conn *Conn) logAndConvertPanic(x interface{}) (err error) {
-	buf := bytes.NewBuffer(nil)
+// logAndConvertPanic returns the error for the recovered panic value x, see
+// convertPanic. The error is logged at LogError, the stack trace of the panic
+// only at LogDebug.
+func (conn *Conn) logAndConvertPanic(funcName string, x interface{}) (err error) {
+	err = convertPanic(funcName, x)
 
-	buf.WriteString(fmt.Sprintf("Error: %v\nStack Trace:\n", x))
-	buf.WriteString("=======================================================\n")
+	if conn.LogLevel >= LogDebug {
+		buf := bytes.NewBuffer(nil)
 
-	i := 0
-	for {
-		pc, file, line, ok := runtime.Caller(i + 3)
-		if !ok {
-			break
-		}
-		if i > 0 {
-			buf.WriteString("-------------------------------------------------------\n")
-		}
+		buf.WriteString(fmt.Sprintf("Error: %v\nStack Trace:\n", err))
+		buf.WriteString("=======================================================\n")
 
-		fun := runtime.FuncForPC(pc)
-		name := fun.Name()
+		i := 0
+		for {
+			pc, file, line, ok := runtime.Caller(i + 3)
+			if !ok {
+				break
+			}
+			if i > 0 {
+				buf.WriteString("-------------------------------------------------------\n")
+			}
 
-		buf.WriteString(fmt.Sprintf("%s (%s, Line %d)\n", name, file, line))
+			fun := runtime.FuncForPC(pc)
+			name := fun.Name()
 
-		i++
-	}
-	buf.WriteString("=======================================================\n")
+			buf.WriteString(fmt.Sprintf("%s (%s, Line %d)\n", name, file, line))
 
-	if conn.LogLevel >= LogError {
-		conn.log(LogError, buf)
-	}
+			i++
+		}
+		buf.WriteString("=======================================================\n")
 
-	err, ok := x.(error)
-	if !ok {
-		err = errors.New(buf.String())
+		conn.log(LogDebug, buf)
+	} else if conn.LogLevel >= LogError {
+		conn.log(LogError, err)
 	}
 
 	return
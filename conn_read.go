@@ -9,15 +9,13 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
-	"strconv"
-	"strings"
 )
 
 func (conn *Conn) read(b []byte) {
 	readTotal := 0
 	for {
 		n, err := conn.reader.Read(b[readTotal:])
-		panicIfErr(err)
+		conn.panicIfIOErr(err)
 
 		readTotal += n
 		if readTotal == len(b) {
@@ -28,14 +26,14 @@ func (conn *Conn) read(b []byte) {
 
 func (conn *Conn) readByte() byte {
 	b, err := conn.reader.ReadByte()
-	panicIfErr(err)
+	conn.panicIfIOErr(err)
 
 	return b
 }
 
 func (conn *Conn) readBytes(delim byte) []byte {
 	b, err := conn.reader.ReadBytes(delim)
-	panicIfErr(err)
+	conn.panicIfIOErr(err)
 
 	return b
 }
@@ -116,7 +114,7 @@ func (conn *Conn) readAuthenticationRequest() {
 		//		case _AuthenticationSSPI:
 
 	default:
-		panic(fmt.Sprintf("unsupported authentication type: %d", authType))
+		panic(fmt.Errorf("unsupported authentication type: %d", authType))
 	}
 }
 
@@ -158,13 +156,10 @@ func (conn *Conn) readCommandComplete(rs *ResultSet) {
 	// Just eat message length.
 	conn.readInt32()
 
-	// Retrieve the number of affected rows from the command tag.
 	tag := conn.readString()
 
 	if rs != nil {
-		parts := strings.Split(tag, " ")
-
-		rs.rowsAffected, _ = strconv.ParseInt(parts[len(parts)-1], 10, 64)
+		rs.commandTag = parseCommandTag(tag)
 		rs.currentResultComplete = true
 	}
 }
@@ -223,6 +218,16 @@ func (conn *Conn) readEmptyQueryResponse() {
 	conn.readInt32()
 }
 
+// setErrorCommand associates err with the command it was caused by, as
+// passed by the caller.
+func (conn *Conn) setErrorCommand(err *Error) {
+	if stmt := conn.activeStmt; stmt != nil {
+		err.setCommand(stmt.command, stmt.actualCommand, stmt.rewrites)
+	} else {
+		err.setCommand(conn.activeCommand, conn.activeCommand, nil)
+	}
+}
+
 func (conn *Conn) readErrorOrNoticeResponse(isError bool) {
 	if conn.LogLevel >= LogDebug {
 		defer conn.logExit(conn.logEnter("*Conn.readErrorOrNoticeResponse"))
@@ -238,6 +243,8 @@ func (conn *Conn) readErrorOrNoticeResponse(isError bool) {
 		fieldType := conn.readByte()
 
 		if fieldType == 0 {
+			conn.setErrorCommand(err)
+
 			if isError {
 				if !conn.onErrorDontRequireReadyForQuery {
 					// Before panicking, we have to wait for a ReadyForQuery message.
@@ -247,8 +254,11 @@ func (conn *Conn) readErrorOrNoticeResponse(isError bool) {
 				// We panic with our error as parameter, so the right thing (TM) will happen.
 				panic(err)
 			} else {
-				// For now, we just log notices.
 				conn.logError(LogDebug, err)
+
+				if conn.onNotice != nil {
+					conn.onNotice(&Notice{*err})
+				}
 				return
 			}
 		}
@@ -291,6 +301,24 @@ func (conn *Conn) readErrorOrNoticeResponse(isError bool) {
 
 		case 'R':
 			err.routine = str
+
+		case 'V':
+			err.severityNonLocalized = str
+
+		case 's':
+			err.schemaName = str
+
+		case 't':
+			err.tableName = str
+
+		case 'c':
+			err.columnName = str
+
+		case 'd':
+			err.dataTypeName = str
+
+		case 'n':
+			err.constraintName = str
 		}
 	}
 }
@@ -304,6 +332,26 @@ func (conn *Conn) readNoData() {
 	conn.readInt32()
 }
 
+func (conn *Conn) readParameterDescription(rs *ResultSet) {
+	if conn.LogLevel >= LogDebug {
+		defer conn.logExit(conn.logEnter("*Conn.readParameterDescription"))
+	}
+
+	// Just eat message length.
+	conn.readInt32()
+
+	paramCount := conn.readInt16()
+
+	paramTypes := make([]Type, paramCount)
+	for i := range paramTypes {
+		paramTypes[i] = Type(conn.readInt32())
+	}
+
+	if rs != nil && rs.stmt != nil {
+		rs.stmt.paramTypes = paramTypes
+	}
+}
+
 func (conn *Conn) readParameterStatus() {
 	if conn.LogLevel >= LogDebug {
 		defer conn.logExit(conn.logEnter("*Conn.readParameterStatus"))
@@ -351,39 +399,34 @@ func (conn *Conn) readReadyForQuery(rs *ResultSet) {
 
 	conn.transactionStatus = TransactionStatus(txStatus)
 
+	// The command has been processed, don't keep the statement alive.
+	conn.activeCommand, conn.activeStmt = "", nil
+
 	if rs != nil {
+		rs.currentResultComplete = true
 		rs.allResultsComplete = true
 	}
 
 	conn.state = readyState{}
 }
 
-func (conn *Conn) readRowDescription(rs *ResultSet) {
+func (conn *Conn) readRowDescription() []field {
 	// Just eat message length.
 	conn.readInt32()
 
 	fieldCount := conn.readInt16()
 
-	rs.fields = make([]field, fieldCount)
-	rs.values = make([][]byte, fieldCount)
+	fields := make([]field, fieldCount)
 
 	var ord int16
 	for ord = 0; ord < fieldCount; ord++ {
-		rs.fields[ord].name = conn.readString()
-
-		// Just eat table OID.
-		conn.readInt32()
+		fields[ord].name = conn.readString()
 
-		// Just eat field OID.
-		conn.readInt16()
-
-		rs.fields[ord].typeOID = conn.readInt32()
-
-		// Just eat field size.
-		conn.readInt16()
-
-		// Just eat field type modifier.
-		conn.readInt32()
+		fields[ord].tableOID = conn.readInt32()
+		fields[ord].attNum = conn.readInt16()
+		fields[ord].typeOID = conn.readInt32()
+		fields[ord].typeSize = conn.readInt16()
+		fields[ord].typeModifier = conn.readInt32()
 
 		format := fieldFormat(conn.readInt16())
 		switch format {
@@ -391,10 +434,12 @@ func (conn *Conn) readRowDescription(rs *ResultSet) {
 			// nop
 
 		default:
-			panic("unsupported field format")
+			panic(fmt.Errorf("unsupported field format: %d", format))
 		}
-		rs.fields[ord].format = format
+		fields[ord].format = format
 	}
+
+	return fields
 }
 
 func (conn *Conn) readBackendMessages(rs *ResultSet) {
@@ -448,6 +493,9 @@ func (conn *Conn) readBackendMessages(rs *ResultSet) {
 		case _NoticeResponse:
 			conn.readErrorOrNoticeResponse(false)
 
+		case _ParameterDescription:
+			conn.readParameterDescription(rs)
+
 		case _ParameterStatus:
 			conn.readParameterStatus()
 
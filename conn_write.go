@@ -6,25 +6,29 @@ package pgsql
 
 import (
 	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math"
 	"math/big"
+	"net"
 	"strconv"
 	"strings"
 	"time"
 )
 
 func (conn *Conn) flush() {
-	panicIfErr(conn.writer.Flush())
+	conn.panicIfIOErr(conn.writer.Flush())
+	conn.flushes++
 }
 
 func (conn *Conn) write(b []byte) {
 	_, err := conn.writer.Write(b)
-	panicIfErr(err)
+	conn.panicIfIOErr(err)
 }
 
 func (conn *Conn) writeByte(b byte) {
-	panicIfErr(conn.writer.WriteByte(b))
+	conn.panicIfIOErr(conn.writer.WriteByte(b))
 }
 
 func (conn *Conn) writeFloat32(f float32) {
@@ -44,7 +48,7 @@ func (conn *Conn) writeFloat64(f float64) {
 }
 
 func (conn *Conn) writeFrontendMessageCode(code frontendMessageCode) {
-	panicIfErr(conn.writer.WriteByte(byte(code)))
+	conn.panicIfIOErr(conn.writer.WriteByte(byte(code)))
 }
 
 func (conn *Conn) writeInt16(i int16) {
@@ -73,7 +77,7 @@ func (conn *Conn) writeInt64(i int64) {
 
 func (conn *Conn) writeString(s string) {
 	_, err := conn.writer.WriteString(s)
-	panicIfErr(err)
+	conn.panicIfIOErr(err)
 }
 
 func (conn *Conn) writeString0(s string) {
@@ -81,21 +85,40 @@ func (conn *Conn) writeString0(s string) {
 	conn.writeByte(0)
 }
 
-func (conn *Conn) writeFlush() {
-	conn.writeFrontendMessageCode(_Flush)
-	conn.writeInt32(4)
+// formatUUID returns the text representation of a UUID, like
+// a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11.
+func formatUUID(u [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
 
-	conn.flush()
+// quoteArrayElement returns s as double-quoted array element, escaping double
+// quotes and backslashes.
+func quoteArrayElement(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+
+	return `"` + s + `"`
 }
 
 func (conn *Conn) writeBind(stmt *Statement) {
 	values := make([]string, len(stmt.params))
+	isNull := make([]bool, len(stmt.params))
 
 	var paramValuesLen int
 	for i, param := range stmt.params {
 		value := param.value
-		if val, ok := value.(uint64); ok {
+		if isNilPtr(value) {
+			// Like nil, e.g. a nil *big.Rat.
+			value = nil
+		}
+		isNull[i] = value == nil
+
+		switch val := value.(type) {
+		case uint64:
 			value = int64(val)
+
+		case big.Rat:
+			value = &val
 		}
 
 		switch val := value.(type) {
@@ -156,6 +179,41 @@ func (conn *Conn) writeBind(stmt *Statement) {
 		case string:
 			values[i] = val
 
+		case []byte:
+			if param.typ == Type(_BYTEAOID) || param.typ == Custom && param.customTypeName == "" {
+				// The hex format keeps NUL bytes, backslashes and invalid
+				// UTF-8 intact.
+				values[i] = `\x` + hex.EncodeToString(val)
+			} else {
+				values[i] = string(val)
+			}
+
+		case json.RawMessage:
+			values[i] = string(val)
+
+		case net.IP:
+			values[i] = val.String()
+
+		case time.Duration:
+			values[i] = fmt.Sprintf("%d microseconds", val.Microseconds())
+
+		case [16]byte:
+			values[i] = formatUUID(val)
+
+		case []int64:
+			elems := make([]string, len(val))
+			for j, elem := range val {
+				elems[j] = strconv.FormatInt(elem, 10)
+			}
+			values[i] = "{" + strings.Join(elems, ",") + "}"
+
+		case []string:
+			elems := make([]string, len(val))
+			for j, elem := range val {
+				elems[j] = quoteArrayElement(elem)
+			}
+			values[i] = "{" + strings.Join(elems, ",") + "}"
+
 		case time.Time:
 			switch param.typ {
 			case Date:
@@ -168,11 +226,11 @@ func (conn *Conn) writeBind(stmt *Statement) {
 				values[i] = val.Format("2006-01-02 15:04:05")
 
 			default:
-				panic("invalid use of time.Time")
+				panic(fmt.Errorf("invalid use of time.Time for parameter %s of type %s", param.name, param.typ))
 			}
 
 		default:
-			panic("unsupported parameter type")
+			panic(fmt.Errorf("unsupported parameter value type: %T", value))
 		}
 
 		paramValuesLen += len(values[i])
@@ -193,8 +251,8 @@ func (conn *Conn) writeBind(stmt *Statement) {
 	conn.writeInt16(int16(textFormat))
 	conn.writeInt16(int16(len(stmt.params)))
 
-	for i, param := range stmt.params {
-		if param.value == nil {
+	for i := range stmt.params {
+		if isNull[i] {
 			conn.writeInt32(-1)
 		} else {
 			conn.writeInt32(int32(len(values[i])))
@@ -204,8 +262,6 @@ func (conn *Conn) writeBind(stmt *Statement) {
 
 	conn.writeInt16(1)
 	conn.writeInt16(int16(textFormat))
-
-	conn.writeFlush()
 }
 
 func (conn *Conn) writeClose(itemType byte, itemName string) {
@@ -215,19 +271,15 @@ func (conn *Conn) writeClose(itemType byte, itemName string) {
 	conn.writeInt32(msgLen)
 	conn.writeByte(itemType)
 	conn.writeString0(itemName)
-
-	conn.flush()
 }
 
-func (conn *Conn) writeDescribe(stmt *Statement) {
-	msgLen := int32(4 + 1 + len(stmt.portalName) + 1)
+func (conn *Conn) writeDescribe(itemType byte, itemName string) {
+	msgLen := int32(4 + 1 + len(itemName) + 1)
 
 	conn.writeFrontendMessageCode(_Describe)
 	conn.writeInt32(msgLen)
-	conn.writeByte('P')
-	conn.writeString0(stmt.portalName)
-
-	conn.writeFlush()
+	conn.writeByte(itemType)
+	conn.writeString0(itemName)
 }
 
 func (conn *Conn) writeExecute(stmt *Statement) {
@@ -237,8 +289,6 @@ func (conn *Conn) writeExecute(stmt *Statement) {
 	conn.writeInt32(msgLen)
 	conn.writeString0(stmt.portalName)
 	conn.writeInt32(0)
-
-	conn.writeFlush()
 }
 
 func (conn *Conn) writeParse(stmt *Statement) {
@@ -272,8 +322,6 @@ func (conn *Conn) writeParse(stmt *Statement) {
 		}
 		conn.writeInt32(int32(typ))
 	}
-
-	conn.writeFlush()
 }
 
 func (conn *Conn) writePasswordMessage(password string) {
@@ -331,11 +379,13 @@ func (conn *Conn) writeStartup() {
 	conn.flush()
 }
 
+// writeSync sends a Sync packet, together with all extended query packets
+// that have been buffered before, to the server.
 func (conn *Conn) writeSync() {
 	conn.writeFrontendMessageCode(_Sync)
 	conn.writeInt32(4)
 
-	conn.writeFlush()
+	conn.flush()
 }
 
 func (conn *Conn) writeTerminate() {
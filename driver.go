@@ -5,10 +5,16 @@
 package pgsql
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"net"
+	"reflect"
 	"time"
 )
 
@@ -28,12 +34,152 @@ func (sqlDriver) Open(name string) (driver.Conn, error) {
 	return &sqlConn{conn}, nil
 }
 
+func (sqlDriver) OpenConnector(name string) (driver.Connector, error) {
+	return &sqlConnector{connStr: name}, nil
+}
+
+type sqlConnector struct {
+	connStr string
+	config  *ConnConfig
+}
+
+// NewConnector returns a driver.Connector for use with sql.OpenDB, which
+// establishes connections using the settings in config.
+func NewConnector(config *ConnConfig) driver.Connector {
+	c := *config
+
+	return &sqlConnector{config: &c}
+}
+
+func (c *sqlConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	var conn *Conn
+	var err error
+
+	if c.config != nil {
+		conn, err = connectConfigContext(ctx, c.config, LogNothing)
+	} else {
+		conn, err = connectContext(ctx, c.connStr, LogNothing)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqlConn{conn}, nil
+}
+
+func (c *sqlConnector) Driver() driver.Driver {
+	return sqlDriver{}
+}
+
+// watchCancel cancels the command conn is processing when ctx is done, until
+// the returned function is called.
+func watchCancel(ctx context.Context, conn *Conn) (finish func()) {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+
+		select {
+		case <-ctx.Done():
+			conn.Cancel()
+
+		case <-done:
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// badConnErr returns driver.ErrBadConn instead of err, if the connection broke
+// before anything was sent to the server, so database/sql can safely retry the
+// operation on another connection. flushes is the number of successful
+// flushes of the connection before the operation.
+//
+// Once a flush succeeded, the server may have executed the command, so err is
+// returned to avoid executing it twice. If a write or flush failed, the last
+// message, i.e. Sync or Query, is incomplete, so the server didn't commit or
+// even execute it.
+func badConnErr(conn *Conn, flushes uint64, err error) error {
+	if err != nil && conn.Status() == StatusDisconnected && conn.flushes == flushes {
+		return driver.ErrBadConn
+	}
+
+	return err
+}
+
+// withContext calls f, canceling the command it sends when ctx is done. If f
+// fails because of that, the error of ctx is returned.
+func withContext(ctx context.Context, conn *Conn, f func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if conn.Status() == StatusDisconnected {
+		return driver.ErrBadConn
+	}
+
+	flushes := conn.flushes
+
+	finish := watchCancel(ctx, conn)
+	err := f()
+	finish()
+
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return badConnErr(conn, flushes, err)
+}
+
+// argsFromNamedValues returns the args for Conn.Query and friends. Values
+// passed with sql.Named become *Parameter values, which can be referenced as
+// :name or @name in the command, all other values are for $n placeholders.
+func argsFromNamedValues(vals []driver.NamedValue) ([]interface{}, error) {
+	args := make([]interface{}, len(vals))
+
+	var named int
+	for i, val := range vals {
+		if val.Name != "" {
+			args[i] = valueParam("@"+val.Name, val.Value)
+			named++
+		} else {
+			args[i] = val.Value
+		}
+	}
+
+	if named > 0 && named < len(vals) {
+		return nil, errors.New("pgsql: named and positional arguments can't be mixed")
+	}
+
+	return args, nil
+}
+
+// CheckNamedValue accepts the values the driver knows to send in addition
+// to the default driver.Value types. Everything else is left to the default
+// conversion, which also takes care of driver.Valuer.
+func (c *sqlConn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch nv.Value.(type) {
+	case []int64, []string, json.RawMessage, net.IP, time.Duration, big.Rat, *big.Rat, [16]byte:
+		return nil
+	}
+
+	return driver.ErrSkip
+}
+
 type sqlConn struct {
 	conn *Conn
 }
 
 func (c *sqlConn) Exec(query string, args []driver.Value) (driver.Result, error) {
-	n, err := c.conn.Execute(query, paramsFromValues(nil, args)...)
+	n, err := c.conn.Execute(query, argsFromValues(args)...)
 	if err != nil {
 		return nil, err
 	}
@@ -41,6 +187,65 @@ func (c *sqlConn) Exec(query string, args []driver.Value) (driver.Result, error)
 	return driver.RowsAffected(n), nil
 }
 
+func (c *sqlConn) ExecContext(ctx context.Context, query string, namedArgs []driver.NamedValue) (driver.Result, error) {
+	args, err := argsFromNamedValues(namedArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	var n int64
+	err = withContext(ctx, c.conn, func() (err error) {
+		n, err = c.conn.Execute(query, args...)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(n), nil
+}
+
+func (c *sqlConn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	rs, err := c.conn.Query(query, argsFromValues(args)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqlRows{rs: rs}, nil
+}
+
+func (c *sqlConn) QueryContext(ctx context.Context, query string, namedArgs []driver.NamedValue) (driver.Rows, error) {
+	args, err := argsFromNamedValues(namedArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if c.conn.Status() == StatusDisconnected {
+		return nil, driver.ErrBadConn
+	}
+
+	flushes := c.conn.flushes
+
+	// Fetching rows may take a while, so we keep watching ctx
+	// until the rows get closed.
+	finish := watchCancel(ctx, c.conn)
+
+	rs, err := c.conn.Query(query, args...)
+	if err != nil {
+		finish()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, badConnErr(c.conn, flushes, err)
+	}
+
+	return &sqlRows{rs: rs, finish: finish}, nil
+}
+
 func (c *sqlConn) Prepare(query string) (driver.Stmt, error) {
 	stmt, err := c.conn.Prepare(query)
 	if err != nil {
@@ -50,32 +255,157 @@ func (c *sqlConn) Prepare(query string) (driver.Stmt, error) {
 	return &sqlStmt{stmt}, nil
 }
 
+func (c *sqlConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt *Statement
+	err := withContext(ctx, c.conn, func() (err error) {
+		stmt, err = c.conn.Prepare(query)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqlStmt{stmt}, nil
+}
+
 func (c *sqlConn) Close() error {
 	return c.conn.Close()
 }
 
 func (c *sqlConn) Begin() (driver.Tx, error) {
-	if _, err := c.conn.Execute("BEGIN;"); err != nil {
+	tx, err := c.conn.Begin(TxOptions{Isolation: DefaultIsolation})
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqlTx{tx}, nil
+}
+
+func (c *sqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var txOpts TxOptions
+
+	switch sql.IsolationLevel(opts.Isolation) {
+	case sql.LevelDefault:
+		txOpts.Isolation = DefaultIsolation
+
+	case sql.LevelReadUncommitted:
+		txOpts.Isolation = ReadUncommittedIsolation
+
+	case sql.LevelReadCommitted:
+		txOpts.Isolation = ReadCommittedIsolation
+
+	case sql.LevelRepeatableRead:
+		txOpts.Isolation = RepeatableReadIsolation
+
+	case sql.LevelSerializable:
+		txOpts.Isolation = SerializableIsolation
+
+	default:
+		return nil, errors.New(fmt.Sprintf("pgsql: unsupported isolation level: %v", sql.IsolationLevel(opts.Isolation)))
+	}
+
+	if opts.ReadOnly {
+		txOpts.AccessMode = ReadOnlyAccess
+	}
+
+	var tx *Tx
+	err := withContext(ctx, c.conn, func() (err error) {
+		tx, err = c.conn.Begin(txOpts)
+		return
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return &sqlTx{c.conn}, nil
+	return &sqlTx{tx}, nil
+}
+
+func (c *sqlConn) Ping(ctx context.Context) error {
+	return withContext(ctx, c.conn, func() (err error) {
+		_, err = c.conn.Execute("SELECT 1;")
+		return
+	})
+}
+
+// ResetSession is called by database/sql before reusing the connection. A
+// connection that is still processing a command or is in a transaction, which
+// cannot be rolled back, is reported as bad, so it gets discarded.
+func (c *sqlConn) ResetSession(ctx context.Context) error {
+	if c.conn.Status() != StatusReady {
+		return driver.ErrBadConn
+	}
+
+	if c.conn.TransactionStatus() != NotInTransaction {
+		if _, err := c.conn.Execute("ROLLBACK;"); err != nil {
+			return driver.ErrBadConn
+		}
+	}
+
+	if c.conn.discardAllOnReset {
+		err := withContext(ctx, c.conn, func() error {
+			return c.conn.withRecover("*sqlConn.ResetSession", c.conn.discardAll)
+		})
+		if err != nil {
+			return driver.ErrBadConn
+		}
+	}
+
+	return nil
+}
+
+// IsValid is called by database/sql before putting the connection back into
+// the pool.
+func (c *sqlConn) IsValid() bool {
+	return c.conn.Status() == StatusReady
 }
 
 type sqlStmt struct {
 	stmt *Statement
 }
 
+// prepareIfDiscarded prepares the statement again, if DISCARD ALL dropped it
+// when database/sql reset the session.
+func (s *sqlStmt) prepareIfDiscarded() error {
+	if s.stmt.isClosed || !s.stmt.isDiscarded() {
+		return nil
+	}
+
+	stmt, err := s.stmt.conn.Prepare(s.stmt.command)
+	if err != nil {
+		return err
+	}
+
+	s.stmt = stmt
+
+	return nil
+}
+
 func (s *sqlStmt) Close() error {
 	return s.stmt.Close()
 }
 
 func (s *sqlStmt) NumInput() int {
-	return -1
+	return len(s.stmt.params)
+}
+
+func (s *sqlStmt) setValues(args []interface{}) error {
+	for i, arg := range args {
+		if _, ok := arg.(*Parameter); ok {
+			return errors.New("pgsql: named arguments are not supported with prepared statements")
+		}
+
+		if err := s.stmt.params[i].SetValue(arg); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (s *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
-	s.stmt.params = paramsFromValues(s.stmt.params, args)
+	if err := s.setValues(argsFromValues(args)); err != nil {
+		return nil, err
+	}
 
 	n, err := s.stmt.Execute()
 	if err != nil {
@@ -85,35 +415,99 @@ func (s *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
 	return driver.RowsAffected(n), nil
 }
 
+func (s *sqlStmt) ExecContext(ctx context.Context, namedArgs []driver.NamedValue) (driver.Result, error) {
+	args, err := argsFromNamedValues(namedArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	var n int64
+	err = withContext(ctx, s.stmt.conn, func() (err error) {
+		if err = s.prepareIfDiscarded(); err != nil {
+			return
+		}
+
+		if err = s.setValues(args); err != nil {
+			return
+		}
+
+		n, err = s.stmt.Execute()
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(n), nil
+}
+
 func (s *sqlStmt) Query(args []driver.Value) (driver.Rows, error) {
-	s.stmt.params = paramsFromValues(s.stmt.params, args)
+	if err := s.setValues(argsFromValues(args)); err != nil {
+		return nil, err
+	}
 
 	rs, err := s.stmt.Query()
 	if err != nil {
 		return nil, err
 	}
 
-	return &sqlRows{rs}, nil
+	return &sqlRows{rs: rs}, nil
+}
+
+func (s *sqlStmt) QueryContext(ctx context.Context, namedArgs []driver.NamedValue) (driver.Rows, error) {
+	args, err := argsFromNamedValues(namedArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	conn := s.stmt.conn
+	if conn.Status() == StatusDisconnected {
+		return nil, driver.ErrBadConn
+	}
+
+	flushes := conn.flushes
+
+	if err := s.prepareIfDiscarded(); err != nil {
+		return nil, badConnErr(conn, flushes, err)
+	}
+
+	if err := s.setValues(args); err != nil {
+		return nil, err
+	}
+
+	finish := watchCancel(ctx, conn)
+
+	rs, err := s.stmt.Query()
+	if err != nil {
+		finish()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, badConnErr(conn, flushes, err)
+	}
+
+	return &sqlRows{rs: rs, finish: finish}, nil
 }
 
 type sqlTx struct {
-	conn *Conn
+	tx *Tx
 }
 
 func (t *sqlTx) Commit() error {
-	_, err := t.conn.Execute("COMMIT;")
-
-	return err
+	return t.tx.Commit()
 }
 
 func (t *sqlTx) Rollback() error {
-	_, err := t.conn.Execute("ROLLBACK;")
-
-	return err
+	return t.tx.Rollback()
 }
 
 type sqlRows struct {
-	rs *ResultSet
+	rs     *ResultSet
+	finish func() // Stops watching for the cancellation of a context
 }
 
 func (r *sqlRows) Columns() []string {
@@ -126,8 +520,85 @@ func (r *sqlRows) Columns() []string {
 	return names
 }
 
+func (r *sqlRows) ColumnTypeDatabaseTypeName(index int) string {
+	return typeName(r.rs.fields[index].typeOID)
+}
+
+var (
+	boolType    = reflect.TypeOf(false)
+	bytesType   = reflect.TypeOf([]byte(nil))
+	float64Type = reflect.TypeOf(float64(0))
+	int64Type   = reflect.TypeOf(int64(0))
+	ratType     = reflect.TypeOf((*big.Rat)(nil))
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// ColumnTypeScanType returns the type of the values Next stores for the
+// column.
+func (r *sqlRows) ColumnTypeScanType(index int) reflect.Type {
+	switch r.rs.fields[index].typeOID {
+	case _BOOLOID:
+		return boolType
+
+	case _DATEOID, _TIMEOID, _TIMETZOID, _TIMESTAMPOID, _TIMESTAMPTZOID:
+		return timeType
+
+	case _FLOAT4OID, _FLOAT8OID:
+		return float64Type
+
+	case _INT2OID, _INT4OID, _INT8OID:
+		return int64Type
+
+	case _NUMERICOID:
+		return ratType
+	}
+
+	return bytesType
+}
+
+// ColumnTypeNullable always reports ok == false, because a RowDescription
+// carries no information about nullability.
+func (r *sqlRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return false, false
+}
+
+func (r *sqlRows) ColumnTypeLength(index int) (length int64, ok bool) {
+	return r.rs.fields[index].length()
+}
+
+func (r *sqlRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	return r.rs.fields[index].precisionScale()
+}
+
+// HasNextResultSet reports whether there may be another result. As this is
+// only known after reading from the server, NextResultSet may still return
+// io.EOF.
+func (r *sqlRows) HasNextResultSet() bool {
+	return !r.rs.allResultsComplete
+}
+
+func (r *sqlRows) NextResultSet() error {
+	hasResult, err := r.rs.NextResult()
+	if err != nil {
+		return err
+	}
+
+	if !hasResult {
+		return io.EOF
+	}
+
+	return nil
+}
+
 func (r *sqlRows) Close() error {
-	return r.rs.Close()
+	err := r.rs.Close()
+
+	if r.finish != nil {
+		r.finish()
+		r.finish = nil
+	}
+
+	return err
 }
 
 func (r *sqlRows) Next(dest []driver.Value) error {
@@ -176,46 +647,12 @@ func (r *sqlRows) Next(dest []driver.Value) error {
 	return nil
 }
 
-func paramsFromValues(params []*Parameter, vals []driver.Value) []*Parameter {
-	if len(params) < len(vals) {
-		params = make([]*Parameter, len(vals))
-	}
+func argsFromValues(vals []driver.Value) []interface{} {
+	args := make([]interface{}, len(vals))
 
 	for i, val := range vals {
-		p := params[i]
-
-		if p == nil {
-			var typ Type
-
-			switch val.(type) {
-			case nil:
-				typ = Integer
-
-			case bool:
-				typ = Boolean
-
-			case []byte, string:
-				typ = Varchar
-
-			case float64:
-				typ = Double
-
-			case int64:
-				typ = Bigint
-
-			case time.Time:
-				typ = TimestampTZ
-
-			default:
-				panic("unexpected value type")
-			}
-
-			p = NewParameter(fmt.Sprintf("$%d", i), typ)
-			params[i] = p
-		}
-
-		p.SetValue(val)
+		args[i] = val
 	}
 
-	return params
+	return args
 }
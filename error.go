@@ -5,18 +5,25 @@
 package pgsql
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 // Error contains detailed error information received from a PostgreSQL backend.
 //
-// Many go-pgsql functions return an os.Error value. In case of a backend error,
-// a type assertion as shown below gives you a *pgsql.Error with all details:
+// go-pgsql functions return errors wrapped in an *OpError. In case of a
+// backend error, errors.As as shown below gives you a *pgsql.Error with all
+// details:
 //
 //	...
 //	_, err := rs.FetchNext()
 //	if err != nil {
-//		if pgerr, ok := err.(*pgsql.Error); ok {
+//		var pgerr *pgsql.Error
+//		if errors.As(err, &pgerr) {
 //			// Do something with pgerr
 //		}
 //	}
@@ -34,6 +41,16 @@ type Error struct {
 	file             string
 	line             string
 	routine          string
+
+	severityNonLocalized string
+	schemaName           string
+	tableName            string
+	columnName           string
+	dataTypeName         string
+	constraintName       string
+
+	command        string // Command the error was caused by, as passed by the caller
+	actualPosition string // Position in the command sent to the server
 }
 
 func (e *Error) Severity() string {
@@ -44,6 +61,31 @@ func (e *Error) Code() string {
 	return e.code
 }
 
+// SQLState returns the SQLSTATE error code, see Code.
+func (e *Error) SQLState() SQLState {
+	return SQLState(e.code)
+}
+
+// Class returns the class of the SQLSTATE error code, i.e. its first two
+// characters, like "23" for integrity constraint violations.
+func (e *Error) Class() string {
+	return e.SQLState().Class()
+}
+
+// Is makes errors.Is work with SQLState codes, see SQLState.
+func (e *Error) Is(target error) bool {
+	code, ok := target.(SQLState)
+	if !ok {
+		return false
+	}
+
+	if code.isClass() {
+		return code.Class() == e.Class()
+	}
+
+	return code == e.SQLState()
+}
+
 func (e *Error) Message() string {
 	return e.message
 }
@@ -56,10 +98,60 @@ func (e *Error) Hint() string {
 	return e.hint
 }
 
+// Position returns the 1-based character position of the error in Command.
+//
+// The server reports the position in the command sent to it, see
+// *Statement.ActualCommand, which is mapped back to the command passed by the
+// caller.
 func (e *Error) Position() string {
 	return e.position
 }
 
+// ActualPosition returns the position of the error as reported by the server,
+// i.e. in the command actually sent to it.
+func (e *Error) ActualPosition() string {
+	if e.actualPosition == "" {
+		return e.position
+	}
+
+	return e.actualPosition
+}
+
+// Command returns the command that caused the error, as passed by the caller.
+// It is empty for errors that are not caused by a command.
+func (e *Error) Command() string {
+	return e.command
+}
+
+// setCommand records command as the command that caused the error and maps
+// the position from actualCommand back to it.
+func (e *Error) setCommand(command, actualCommand string, rewrites []paramRewrite) {
+	if command == "" {
+		return
+	}
+
+	e.command = command
+
+	pos, err := strconv.Atoi(e.position)
+	if err != nil || pos < 1 {
+		return
+	}
+
+	// The position counts characters, not bytes.
+	offset := len(actualCommand)
+	for i := range actualCommand {
+		if pos--; pos == 0 {
+			offset = i
+			break
+		}
+	}
+
+	offset = min(originalOffset(rewrites, offset), len(command))
+
+	e.actualPosition = e.position
+	e.position = strconv.Itoa(utf8.RuneCountInString(command[:offset]) + 1)
+}
+
 func (e *Error) InternalPosition() string {
 	return e.internalPosition
 }
@@ -84,6 +176,42 @@ func (e *Error) Routine() string {
 	return e.routine
 }
 
+// SeverityNonLocalized returns the severity, like ERROR or FATAL, which
+// unlike Severity is never localized. Servers before 9.6 don't send it.
+func (e *Error) SeverityNonLocalized() string {
+	return e.severityNonLocalized
+}
+
+// SchemaName returns the name of the schema containing the database object
+// the error is associated with, if any.
+func (e *Error) SchemaName() string {
+	return e.schemaName
+}
+
+// TableName returns the name of the table the error is associated with, if
+// any.
+func (e *Error) TableName() string {
+	return e.tableName
+}
+
+// ColumnName returns the name of the table column the error is associated
+// with, if any.
+func (e *Error) ColumnName() string {
+	return e.columnName
+}
+
+// DataTypeName returns the name of the data type the error is associated
+// with, if any.
+func (e *Error) DataTypeName() string {
+	return e.dataTypeName
+}
+
+// ConstraintName returns the name of the constraint the error is associated
+// with, if any, e.g. the violated unique index for a UniqueViolation.
+func (e *Error) ConstraintName() string {
+	return e.constraintName
+}
+
 func (e *Error) Error() string {
 	return fmt.Sprintf(
 		`Severity: %s
@@ -97,7 +225,108 @@ func (e *Error) Error() string {
 		Where: %s
 		File: %s
 		Line: %s
-		Routine: %s`,
+		Routine: %s
+		Schema: %s
+		Table: %s
+		Column: %s
+		Data Type: %s
+		Constraint: %s`,
 		e.severity, e.code, e.message, e.detail, e.hint, e.position,
-		e.internalPosition, e.internalQuery, e.where, e.file, e.line, e.routine)
+		e.internalPosition, e.internalQuery, e.where, e.file, e.line, e.routine,
+		e.schemaName, e.tableName, e.columnName, e.dataTypeName, e.constraintName)
+}
+
+// Notice is a notice or warning received from the server, like the output
+// of RAISE NOTICE in a PL/pgSQL function or a deprecation warning. It has the
+// same fields as an Error, e.g. Severity, Code and Message.
+type Notice struct {
+	Error
+}
+
+// writeErrorLine writes the line of command containing the 1-based character
+// position pos, preceded by label, and a caret below the position.
+func writeErrorLine(buf *bytes.Buffer, label, command, pos string) {
+	n, err := strconv.Atoi(pos)
+	if err != nil || n < 1 {
+		return
+	}
+
+	lineNo := 1
+	lineStart := 0
+	offset := len(command)
+
+	for i, r := range command {
+		if n--; n == 0 {
+			offset = i
+			break
+		}
+		if r == '\n' {
+			lineNo++
+			lineStart = i + 1
+		}
+	}
+
+	line := command[lineStart:]
+	if end := strings.IndexByte(line, '\n'); end != -1 {
+		line = line[:end]
+	}
+	line = strings.TrimSuffix(line, "\r")
+
+	if label == "" {
+		label = fmt.Sprintf("LINE %d: ", lineNo)
+	}
+
+	buf.WriteString(label)
+	buf.WriteString(line)
+	buf.WriteString("\n")
+
+	buf.WriteString(strings.Repeat(" ", len(label)))
+	// Keep tabs, so the caret lines up with the position.
+	for _, r := range command[lineStart:offset] {
+		if r == '\t' {
+			buf.WriteByte('\t')
+		} else {
+			buf.WriteByte(' ')
+		}
+	}
+	buf.WriteString("^\n")
+}
+
+// FormatError returns err formatted like psql shows errors, including the
+// line of the command the error position refers to, with a caret below it:
+//
+//	ERROR:  syntax error at or near "FORM"
+//	LINE 1: SELECT * FORM table1;
+//	                 ^
+//
+// If err is not caused by an *Error, err.Error() is returned.
+func FormatError(err error) string {
+	var e *Error
+	if !errors.As(err, &e) {
+		return err.Error()
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	buf.WriteString(fmt.Sprintf("%s:  %s\n", e.severity, e.message))
+
+	if e.command != "" {
+		writeErrorLine(buf, "", e.command, e.position)
+	}
+	if e.detail != "" {
+		buf.WriteString(fmt.Sprintf("DETAIL:  %s\n", e.detail))
+	}
+	if e.hint != "" {
+		buf.WriteString(fmt.Sprintf("HINT:  %s\n", e.hint))
+	}
+	if e.internalPosition != "" {
+		writeErrorLine(buf, "QUERY:  ", e.internalQuery, e.internalPosition)
+	} else if e.internalQuery != "" {
+		buf.WriteString(fmt.Sprintf("QUERY:  %s\n", e.internalQuery))
+	}
+	if e.where != "" {
+		buf.WriteString(fmt.Sprintf("CONTEXT:  %s\n", e.where))
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n")
 }
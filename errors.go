@@ -0,0 +1,90 @@
+// Copyright 2026 The go-pgsql Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pgsql
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrConnClosed is returned by operations on a connection that has been
+	// closed, either by Close or because of an I/O error.
+	ErrConnClosed = errors.New("pgsql: connection is closed")
+
+	// ErrInvalidState is returned by operations that are not allowed in the
+	// current state of the connection or transaction, e.g. sending a query
+	// while the results of another one are still being read.
+	ErrInvalidState = errors.New("pgsql: invalid operation for this state")
+
+	// ErrNoCurrentRow is returned when reading field values of a ResultSet
+	// without a current row, i.e. before FetchNext returned true or after it
+	// returned false.
+	ErrNoCurrentRow = errors.New("pgsql: no current row")
+
+	// ErrValueOutOfRange is returned when setting a Parameter to an integer
+	// that doesn't fit into its PostgreSQL type.
+	ErrValueOutOfRange = errors.New("pgsql: value out of range")
+
+	// ErrTxFailed is returned when the transaction has failed, so the server
+	// ignores further commands until it is rolled back.
+	ErrTxFailed = errors.New("pgsql: error in transaction")
+)
+
+// ArgumentCountError is returned if the number of arguments doesn't match
+// the number of parameters of a command or fields of a ResultSet.
+type ArgumentCountError struct {
+	Have int // Number of arguments given
+	Want int // Number of arguments expected
+}
+
+func (e *ArgumentCountError) Error() string {
+	return fmt.Sprintf("pgsql: wrong argument count, have: %d, want: %d", e.Have, e.Want)
+}
+
+// OpError is the error returned by the methods of Conn, ResultSet, Statement
+// and the other types of this package. It records the failed operation and
+// wraps the underlying error, like an *Error sent by the server,
+// ErrConnClosed or an I/O error, which can be checked with errors.Is and
+// errors.As:
+//
+//	var pgErr *pgsql.Error
+//	if errors.As(err, &pgErr) {
+//		// Do something with pgErr
+//	}
+type OpError struct {
+	Op  string // Failed operation, e.g. "*Conn.Execute"
+	Err error
+}
+
+func (e *OpError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Op, e.Err)
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// convertPanic returns the error for the recovered panic value x, wrapped in
+// an *OpError for funcName, unless it already is one.
+func convertPanic(funcName string, x interface{}) error {
+	var err error
+
+	switch ex := x.(type) {
+	case *OpError:
+		return ex
+
+	case error:
+		err = ex
+
+	case string:
+		err = errors.New(ex)
+
+	default:
+		err = errors.New(fmt.Sprint(ex))
+	}
+
+	return &OpError{Op: funcName, Err: err}
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 The go-pgsql Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pgsql
+
+import (
+	"strings"
+)
+
+// paramRef is a reference to a named parameter, like :name or @name, found
+// in a command.
+type paramRef struct {
+	start int    // Byte offset of the ':' or '@' prefix
+	end   int    // Byte offset following the name
+	name  string // Name without prefix
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= 0x80
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9' || c == '$'
+}
+
+// skipQuoted returns the offset following the quoted string or identifier
+// starting at s[i]. A doubled quote character does not end it, neither does a
+// quote escaped by a backslash, if backslashEscapes is true.
+func skipQuoted(s string, i int, backslashEscapes bool) int {
+	quote := s[i]
+
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			if backslashEscapes {
+				j++
+			}
+
+		case quote:
+			if j+1 < len(s) && s[j+1] == quote {
+				j++
+			} else {
+				return j + 1
+			}
+		}
+	}
+
+	return len(s)
+}
+
+// skipBlockComment returns the offset following the, possibly nested, block
+// comment starting at s[i].
+func skipBlockComment(s string, i int) int {
+	depth := 0
+
+	for j := i; j+1 < len(s); j++ {
+		switch {
+		case s[j] == '/' && s[j+1] == '*':
+			depth++
+			j++
+
+		case s[j] == '*' && s[j+1] == '/':
+			depth--
+			j++
+			if depth == 0 {
+				return j + 1
+			}
+		}
+	}
+
+	return len(s)
+}
+
+// dollarQuoteTag returns the tag, like $$ or $body$, if a dollar-quoted
+// string starts at s[i].
+func dollarQuoteTag(s string, i int) (tag string, ok bool) {
+	j := i + 1
+	if j < len(s) && isIdentStart(s[j]) {
+		for j < len(s) && isIdentChar(s[j]) && s[j] != '$' {
+			j++
+		}
+	}
+
+	if j < len(s) && s[j] == '$' {
+		return s[i : j+1], true
+	}
+
+	return "", false
+}
+
+// findParamRefs returns all references to named parameters in command.
+//
+// Only code is searched, string constants, quoted identifiers, dollar-quoted
+// strings and comments are skipped. If backslashEscapes is true, backslashes
+// escape quotes in all string constants, like they do with
+// standard_conforming_strings turned off, otherwise only in escape string
+// constants like E'\n'.
+func findParamRefs(command string, backslashEscapes bool) (refs []paramRef) {
+	afterIdent := func(i int) bool {
+		return i > 0 && isIdentChar(command[i-1])
+	}
+
+	for i := 0; i < len(command); {
+		c := command[i]
+
+		switch {
+		case c == '\'':
+			escapes := backslashEscapes ||
+				afterIdent(i) && (command[i-1] == 'E' || command[i-1] == 'e') && !afterIdent(i-1)
+			i = skipQuoted(command, i, escapes)
+
+		case c == '"':
+			i = skipQuoted(command, i, false)
+
+		case c == '-' && strings.HasPrefix(command[i:], "--"):
+			if end := strings.IndexByte(command[i:], '\n'); end != -1 {
+				i += end + 1
+			} else {
+				i = len(command)
+			}
+
+		case c == '/' && strings.HasPrefix(command[i:], "/*"):
+			i = skipBlockComment(command, i)
+
+		case c == '$' && !afterIdent(i):
+			if tag, ok := dollarQuoteTag(command, i); ok {
+				if end := strings.Index(command[i+len(tag):], tag); end != -1 {
+					i += len(tag) + end + len(tag)
+				} else {
+					i = len(command)
+				}
+			} else {
+				i++
+			}
+
+		case c == ':' && strings.HasPrefix(command[i:], "::"):
+			// A type cast, not a parameter.
+			i += 2
+
+		case (c == ':' || c == '@') && !afterIdent(i) && i+1 < len(command) && isIdentStart(command[i+1]):
+			end := i + 2
+			for end < len(command) && isIdentChar(command[end]) && command[end] != '$' {
+				end++
+			}
+
+			refs = append(refs, paramRef{i, end, command[i+1 : end]})
+
+			i = end
+
+		case isIdentChar(c):
+			// Skip whole identifiers, so a '$' inside is not mistaken
+			// for the start of a dollar-quoted string.
+			for i < len(command) && isIdentChar(command[i]) {
+				i++
+			}
+
+		default:
+			i++
+		}
+	}
+
+	return
+}
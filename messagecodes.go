@@ -83,6 +83,11 @@ func (x frontendMessageCode) String() string {
 
 //------------------------------------------------------------------------------
 
+// cancelRequestCode is sent instead of a protocol version in a CancelRequest.
+const cancelRequestCode = 80877102
+
+//------------------------------------------------------------------------------
+
 type authenticationType int32
 
 const (
@@ -5,8 +5,11 @@
 package pgsql
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"reflect"
 	"time"
@@ -63,11 +66,74 @@ func (p *Parameter) Value() interface{} {
 	return p.value
 }
 
+// paramsFromArgs returns the parameters for args, which must either all be
+// *Parameter values or all be plain values for $n placeholders.
+//
+// For plain values, parameters named "$1", "$2" and so on are created. Their
+// type is left for the server to infer, unless the Go type of the value
+// determines it.
+func paramsFromArgs(args []interface{}) []*Parameter {
+	params := make([]*Parameter, len(args))
+
+	var named int
+	for i, arg := range args {
+		if p, ok := arg.(*Parameter); ok {
+			params[i] = p
+			named++
+			continue
+		}
+
+		params[i] = valueParam(fmt.Sprintf("$%d", i+1), arg)
+	}
+
+	if named > 0 && named < len(args) {
+		panic(errors.New("*Parameter and plain values can't be mixed"))
+	}
+
+	return params
+}
+
+// valueParam returns a Parameter with the specified name for the plain value
+// v. Values implementing driver.Valuer are replaced by their Value.
+func valueParam(name string, v interface{}) *Parameter {
+	v = driverValue(v)
+
+	var typ Type
+	if _, ok := v.(time.Time); ok {
+		typ = TimestampTZ
+	}
+
+	return &Parameter{name: name, typ: typ, value: v}
+}
+
+// driverValue returns the Value of v, if v implements driver.Valuer, otherwise
+// v itself.
+func driverValue(v interface{}) interface{} {
+	valuer, ok := v.(driver.Valuer)
+	if !ok {
+		return v
+	}
+
+	if isNilPtr(v) {
+		return nil
+	}
+
+	val, err := valuer.Value()
+	panicIfErr(err)
+
+	return val
+}
+
 func (p *Parameter) panicInvalidValue(v interface{}) {
 	panic(errors.New(fmt.Sprintf("Parameter %s: Invalid value for PostgreSQL type %s: '%v' (Go type: %T)",
 		p.name, p.typ, v, v)))
 }
 
+func (p *Parameter) panicOutOfRange(v interface{}) {
+	panic(fmt.Errorf("Parameter %s: %w for PostgreSQL type %s: %v (Go type: %T)",
+		p.name, ErrValueOutOfRange, p.typ, v, v))
+}
+
 func isNilPtr(v interface{}) bool {
 	ptr := reflect.ValueOf(v)
 
@@ -76,6 +142,8 @@ func isNilPtr(v interface{}) bool {
 }
 
 // SetValue sets the current value of the Parameter.
+//
+// If v implements driver.Valuer, the value it returns is used instead.
 func (p *Parameter) SetValue(v interface{}) (err error) {
 	if p.stmt != nil && p.stmt.conn.LogLevel >= LogVerbose {
 		defer p.stmt.conn.logExit(p.stmt.conn.logEnter("*Parameter.SetValue"))
@@ -84,22 +152,21 @@ func (p *Parameter) SetValue(v interface{}) (err error) {
 	defer func() {
 		if x := recover(); x != nil {
 			if p.stmt == nil {
-				switch ex := x.(type) {
-				case error:
-					err = ex
-
-				case string:
-					err = errors.New(ex)
-
-				default:
-					err = errors.New("pgsql.*Parameter.SetValue: D'oh!")
-				}
+				err = convertPanic("*Parameter.SetValue", x)
 			} else {
-				err = p.stmt.conn.logAndConvertPanic(x)
+				err = p.stmt.conn.logAndConvertPanic("*Parameter.SetValue", x)
 			}
 		}
 	}()
 
+	p.setValue(v)
+
+	return
+}
+
+func (p *Parameter) setValue(v interface{}) {
+	v = driverValue(v)
+
 	if v == nil {
 		p.value = nil
 		return
@@ -121,6 +188,9 @@ func (p *Parameter) SetValue(v interface{}) (err error) {
 			p.value = int64(val)
 
 		case uint:
+			if uint64(val) > math.MaxInt64 {
+				p.panicOutOfRange(v)
+			}
 			p.value = int64(val)
 
 		case uint16:
@@ -130,6 +200,9 @@ func (p *Parameter) SetValue(v interface{}) (err error) {
 			p.value = int64(val)
 
 		case uint64:
+			if val > math.MaxInt64 {
+				p.panicOutOfRange(v)
+			}
 			p.value = int64(val)
 
 		case int64:
@@ -147,11 +220,19 @@ func (p *Parameter) SetValue(v interface{}) (err error) {
 		p.value = val
 
 	case Char, Text, Varchar:
-		val, ok := v.(string)
-		if !ok {
+		switch val := v.(type) {
+		case string:
+			p.value = val
+
+		case []byte:
+			p.value = string(val)
+
+		case json.RawMessage:
+			p.value = string(val)
+
+		default:
 			p.panicInvalidValue(v)
 		}
-		p.value = val
 
 	case Custom:
 		p.value = v
@@ -194,45 +275,92 @@ func (p *Parameter) SetValue(v interface{}) (err error) {
 			p.value = int32(val)
 
 		case int:
+			if val < math.MinInt32 || val > math.MaxInt32 {
+				p.panicOutOfRange(v)
+			}
 			p.value = int32(val)
 
 		case int16:
 			p.value = int32(val)
 
 		case uint:
+			if val > math.MaxInt32 {
+				p.panicOutOfRange(v)
+			}
 			p.value = int32(val)
 
 		case uint16:
 			p.value = int32(val)
 
 		case uint32:
+			if val > math.MaxInt32 {
+				p.panicOutOfRange(v)
+			}
 			p.value = int32(val)
 
 		case int32:
 			p.value = val
 
+		case int64:
+			if val < math.MinInt32 || val > math.MaxInt32 {
+				p.panicOutOfRange(v)
+			}
+			p.value = int32(val)
+
 		default:
 			p.panicInvalidValue(v)
 		}
 
 	case Numeric:
-		val, ok := v.(*big.Rat)
-		if !ok {
-			p.panicInvalidValue(v)
-		}
+		switch val := v.(type) {
+		case *big.Rat:
+			if isNilPtr(v) {
+				p.value = nil
+				return
+			}
 
-		if isNilPtr(v) {
-			p.value = nil
-			return
-		}
+			p.value = val
 
-		p.value = val
+		case big.Rat:
+			p.value = &val
+
+		case float64:
+			// NaN and infinities can't be represented by a *big.Rat.
+			r := new(big.Rat).SetFloat64(val)
+			if r == nil {
+				p.panicInvalidValue(v)
+			}
+			p.value = r
+
+		case int64:
+			p.value = new(big.Rat).SetInt64(val)
+
+		case string:
+			r, ok := new(big.Rat).SetString(val)
+			if !ok {
+				p.panicInvalidValue(v)
+			}
+			p.value = r
+
+		case []byte:
+			r, ok := new(big.Rat).SetString(string(val))
+			if !ok {
+				p.panicInvalidValue(v)
+			}
+			p.value = r
+
+		default:
+			p.panicInvalidValue(v)
+		}
 
 	case Real:
 		switch val := v.(type) {
 		case float32:
 			p.value = val
 
+		case float64:
+			p.value = float32(val)
+
 		default:
 			p.panicInvalidValue(v)
 		}
@@ -243,15 +371,49 @@ func (p *Parameter) SetValue(v interface{}) (err error) {
 			p.value = int16(val)
 
 		case uint16:
+			if val > math.MaxInt16 {
+				p.panicOutOfRange(v)
+			}
 			p.value = int16(val)
 
 		case int16:
 			p.value = val
 
+		case int64:
+			if val < math.MinInt16 || val > math.MaxInt16 {
+				p.panicOutOfRange(v)
+			}
+			p.value = int16(val)
+
 		default:
 			p.panicInvalidValue(v)
 		}
+
+	default:
+		// Types we don't know anything about, e.g. those of parameters
+		// created from a ParameterDescription, are passed on as is.
+		p.value = v
 	}
+}
 
-	return
+// setInferredType sets the type the server inferred for the parameter of a
+// $n placeholder and converts its value. Values the type doesn't accept, like
+// an int for a numeric or a string for an integer, are kept as they are and
+// sent as text, so the server converts them. Values out of the range of the
+// type panic with ErrValueOutOfRange.
+func (p *Parameter) setInferredType(typ Type) {
+	value := p.value
+	p.typ = typ
+
+	defer func() {
+		if x := recover(); x != nil {
+			if err, ok := x.(error); ok && errors.Is(err, ErrValueOutOfRange) {
+				panic(err)
+			}
+
+			p.value = value
+		}
+	}()
+
+	p.setValue(value)
 }
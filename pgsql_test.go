@@ -5,11 +5,18 @@
 package pgsql
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"math/big"
+	"net"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -53,7 +60,12 @@ func withSimpleQueryResultSet(t *testing.T, command string, f func(rs *ResultSet
 
 func withStatement(t *testing.T, command string, params []*Parameter, f func(stmt *Statement)) {
 	withConn(t, func(conn *Conn) {
-		stmt, err := conn.Prepare(command, params...)
+		args := make([]interface{}, len(params))
+		for i, p := range params {
+			args[i] = p
+		}
+
+		stmt, err := conn.Prepare(command, args...)
 		if err != nil {
 			t.Error("withStatement: conn.Prepare:", err)
 			return
@@ -122,8 +134,7 @@ func Test_Connect_InvalidPassword_ExpectErrorClass28(t *testing.T) {
 	if err == nil {
 		t.Error("expected err != nil")
 	}
-	// Class 28 == invalid authorization specification
-	if pgerr, ok := err.(*Error); !ok || !strings.HasPrefix(pgerr.Code(), "28") {
+	if !errors.Is(err, InvalidAuthorizationSpecification) {
 		t.Error("expected *pgsql.Error of class 28")
 	}
 	if conn != nil {
@@ -888,7 +899,7 @@ func Test_Query_Exception(t *testing.T) {
 			t.Error("error expected")
 			return
 		}
-		if _, ok := err.(*Error); !ok {
+		if !errors.As(err, new(*Error)) {
 			t.Error("*pgsql.Error expected")
 			return
 		}
@@ -907,7 +918,7 @@ func Test_Query_Exception(t *testing.T) {
 			t.Error("error expected")
 			return
 		}
-		if _, ok := err.(*Error); !ok {
+		if !errors.As(err, new(*Error)) {
 			t.Error("*pgsql.Error expected")
 			return
 		}
@@ -925,7 +936,7 @@ func Test_Query_Exception(t *testing.T) {
 			t.Error("error expected")
 			return
 		}
-		if _, ok := err.(*Error); !ok {
+		if !errors.As(err, new(*Error)) {
 			t.Error("*pgsql.Error expected")
 			return
 		}
@@ -935,7 +946,7 @@ func Test_Query_Exception(t *testing.T) {
 			t.Error("error expected")
 			return
 		}
-		if _, ok := err.(*Error); !ok {
+		if !errors.As(err, new(*Error)) {
 			t.Error("*pgsql.Error expected")
 			return
 		}
@@ -945,7 +956,7 @@ func Test_Query_Exception(t *testing.T) {
 			t.Error("error expected")
 			return
 		}
-		if _, ok := err.(*Error); !ok {
+		if !errors.As(err, new(*Error)) {
 			t.Error("*pgsql.Error expected")
 			return
 		}
@@ -955,7 +966,7 @@ func Test_Query_Exception(t *testing.T) {
 			t.Error("error expected")
 			return
 		}
-		if _, ok := err.(*Error); !ok {
+		if !errors.As(err, new(*Error)) {
 			t.Error("*pgsql.Error expected")
 			return
 		}
@@ -1059,3 +1070,1667 @@ func Test_Issue14_CopyFrom(t *testing.T) {
 		}
 	})
 }
+
+func Test_Statement_QueryRepeatedlyInTransaction(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		idParam := NewParameter("@id", Integer)
+
+		stmt, err := conn.Prepare("SELECT strreq FROM table1 WHERE id = @id;", idParam)
+		if err != nil {
+			t.Error("failed to prepare statement:", err)
+			return
+		}
+		defer stmt.Close()
+
+		err = conn.WithTransaction(ReadCommittedIsolation, func() error {
+			want := []string{"foo", "baz"}
+
+			for i, w := range want {
+				if err := idParam.SetValue(i + 1); err != nil {
+					return err
+				}
+
+				var have string
+				if _, err := stmt.Scan(&have); err != nil {
+					return err
+				}
+
+				if have != w {
+					t.Errorf("id %d - have: '%s', but want '%s'", i+1, have, w)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			t.Error("transaction failed:", err)
+		}
+	})
+}
+
+func Test_stmtCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newStmtCache(2)
+
+	conn := &Conn{}
+	a, b, d := &Statement{conn: conn, name: "a"}, &Statement{conn: conn, name: "b"}, &Statement{conn: conn, name: "d"}
+
+	c.put("a", a)
+	c.put("b", b)
+
+	if c.get("a") != a {
+		t.Error("expected a to be cached")
+	}
+
+	evicted := c.evict(c.capacity - 1)
+	if len(evicted) != 1 || evicted[0] != b {
+		t.Errorf("expected b to be evicted, have: %v", evicted)
+	}
+
+	c.put("d", d)
+
+	if c.get("b") != nil {
+		t.Error("expected b not to be cached")
+	}
+	if c.get("a") != a || c.get("d") != d {
+		t.Error("expected a and d to be cached")
+	}
+}
+
+func Test_stmtCache_EvictReleasesParameters(t *testing.T) {
+	c := newStmtCache(1)
+
+	param := NewParameter("@id", Integer)
+	stmt := &Statement{name: "a"}
+	stmt.setParams([]*Parameter{param})
+
+	c.put("a", stmt)
+	c.evict(0)
+
+	if param.Statement() != nil {
+		t.Error("expected the parameter to be released")
+	}
+
+	other := &Statement{name: "b"}
+	other.setParams([]*Parameter{param})
+
+	if param.Statement() != other {
+		t.Error("expected the parameter to be used by the other statement")
+	}
+}
+
+func Test_Conn_Query_ReusesParameterAfterEviction(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		param := idParameter(1)
+
+		query := func() {
+			rs, err := conn.Query("SELECT id FROM table1 WHERE id = @id;", param)
+			if err != nil {
+				t.Error("failed to query:", err)
+				return
+			}
+			rs.Close()
+		}
+
+		query()
+
+		if err := conn.SetStatementCacheCapacity(0); err != nil {
+			t.Error("failed to disable statement cache:", err)
+			return
+		}
+		query()
+
+		if err := conn.SetStatementCacheCapacity(1); err != nil {
+			t.Error("failed to enable statement cache:", err)
+			return
+		}
+		query()
+
+		if _, err := conn.Execute("SELECT 1 WHERE 1 = $1;", 1); err != nil {
+			t.Error("failed to evict:", err)
+			return
+		}
+		query()
+	})
+}
+
+func Test_Conn_Query_ReusesCachedStatement(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		var stmts []*Statement
+
+		for id := 1; id <= 2; id++ {
+			rs, err := conn.Query("SELECT id FROM table1 WHERE id = @id;", idParameter(id))
+			if err != nil {
+				t.Error("failed to query:", err)
+				return
+			}
+
+			var have int
+			if _, err := rs.ScanNext(&have); err != nil || have != id {
+				t.Errorf("have: %d, but want: %d (err: %v)", have, id, err)
+			}
+			rs.Close()
+
+			stmts = append(stmts, rs.Statement())
+		}
+
+		if stmts[0] != stmts[1] {
+			t.Error("expected the cached statement to be reused")
+		}
+
+		if err := conn.SetStatementCacheCapacity(0); err != nil {
+			t.Error("failed to disable statement cache:", err)
+			return
+		}
+		if !stmts[0].IsClosed() {
+			t.Error("expected the evicted statement to be closed")
+		}
+
+		var one int
+		if _, err := conn.Scan("SELECT 1;", &one); err != nil || one != 1 {
+			t.Error("query after eviction failed:", err)
+		}
+	})
+}
+
+func Test_Statement_DescribeInfersParamTypesAndColumns(t *testing.T) {
+	withStatement(t, "SELECT id, strreq FROM table1 WHERE id = $1;", nil, func(stmt *Statement) {
+		if types := stmt.ParamTypes(); len(types) != 1 || types[0] != Integer {
+			t.Errorf("have param types: %v, but want: [Integer]", types)
+		}
+
+		if columns := stmt.Columns(); len(columns) != 2 || columns[0] != "id" || columns[1] != "strreq" {
+			t.Errorf("have columns: %v, but want: [id strreq]", columns)
+		}
+
+		param := stmt.Parameter("$1")
+		if param == nil {
+			t.Error("expected parameter $1 to be created")
+			return
+		}
+		if err := param.SetValue(int64(2)); err != nil {
+			t.Error("failed to set parameter value:", err)
+			return
+		}
+
+		var id int
+		var strreq string
+		if _, err := stmt.Scan(&id, &strreq); err != nil {
+			t.Error("failed to scan:", err)
+			return
+		}
+		if id != 2 || strreq != "baz" {
+			t.Errorf("have: %d '%s', but want: 2 'baz'", id, strreq)
+		}
+	})
+}
+
+func adjustCommandOrError(command string, params []*Parameter, backslashEscapes bool) (actual string, err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			err = x.(error)
+		}
+	}()
+
+	return adjustCommand(command, params, backslashEscapes), nil
+}
+
+func Test_adjustCommand(t *testing.T) {
+	tests := []struct {
+		command          string
+		backslashEscapes bool
+		want             string
+	}{
+		{"SELECT @id, :id;", false, "SELECT $1, $1;"},
+		{"SELECT '@id', \"@id\", @id;", false, "SELECT '@id', \"@id\", $1;"},
+		{"SELECT 'it''s @id', @id;", false, "SELECT 'it''s @id', $1;"},
+		{"SELECT E'\\' @id', @id;", false, "SELECT E'\\' @id', $1;"},
+		{"SELECT '\\' @id', @id;", true, "SELECT '\\' @id', $1;"},
+		{"SELECT $$ @id $$, $fn$ :id $$ $fn$, @id;", false, "SELECT $$ @id $$, $fn$ :id $$ $fn$, $1;"},
+		{"SELECT @id -- @id\n;", false, "SELECT $1 -- @id\n;"},
+		{"SELECT /* @id /* @id */ */ @id;", false, "SELECT /* @id /* @id */ */ $1;"},
+		{"SELECT @id::text, arr[1:2], @id;", false, "SELECT $1::text, arr[1:2], $1;"},
+		{"SELECT x@id FROM t WHERE id = (@id);", false, "SELECT x@id FROM t WHERE id = ($1);"},
+	}
+
+	for _, test := range tests {
+		have, err := adjustCommandOrError(test.command, []*Parameter{NewParameter("@id", Integer)}, test.backslashEscapes)
+		if err != nil {
+			t.Errorf("'%s' failed: %v", test.command, err)
+		} else if have != test.want {
+			t.Errorf("'%s' failed - have: '%s', but want '%s'", test.command, have, test.want)
+		}
+	}
+}
+
+func Test_adjustCommand_CustomType(t *testing.T) {
+	have := adjustCommand("SELECT @mood, @mood;", []*Parameter{NewCustomTypeParameter("@mood", "mood")}, false)
+	if want := "SELECT $1::mood, $1::mood;"; have != want {
+		t.Errorf("have: '%s', but want '%s'", have, want)
+	}
+}
+
+func Test_adjustCommand_UnknownOrUnusedParameter_ExpectError(t *testing.T) {
+	params := []*Parameter{NewParameter("@id", Integer)}
+
+	if _, err := adjustCommandOrError("SELECT @id, @other;", params, false); err == nil {
+		t.Error("expected error for unknown parameter")
+	}
+
+	if _, err := adjustCommandOrError("SELECT '@id';", params, false); err == nil {
+		t.Error("expected error for unused parameter")
+	}
+}
+
+func Test_Conn_Query_PositionalValues(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		rs, err := conn.Query("SELECT strreq FROM table1 WHERE id = $1 AND blnreq = $2;", 1, true)
+		if err != nil {
+			t.Error("failed to query:", err)
+			return
+		}
+		defer rs.Close()
+
+		var strreq string
+		if fetched, err := rs.ScanNext(&strreq); err != nil || !fetched {
+			t.Error("failed to scan:", err)
+			return
+		}
+		if strreq != "foo" {
+			t.Errorf("have: '%s', but want 'foo'", strreq)
+		}
+	})
+}
+
+func Test_Conn_Execute_WrongValueCount_ExpectError(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		if _, err := conn.Execute("SELECT id FROM table1 WHERE id = $1 OR id = $2;", 1); err == nil {
+			t.Error("expected error")
+		}
+
+		var one int
+		if _, err := conn.Scan("SELECT 1;", &one); err != nil {
+			t.Error("*Conn.Scan failed after previous expected error:", err)
+		}
+	})
+}
+
+func Test_paramsFromArgs_MixedArgs_ExpectPanic(t *testing.T) {
+	defer func() {
+		if x := recover(); x == nil {
+			t.Error("expected panic")
+		}
+	}()
+
+	paramsFromArgs([]interface{}{idParameter(1), 2})
+}
+
+func Test_Parameter_setInferredType(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		typ   Type
+		want  interface{}
+	}{
+		{10, Double, 10},
+		{float32(1.5), Double, float64(1.5)},
+		{10, Numeric, 10},
+		{int64(10), Numeric, big.NewRat(10, 1)},
+		{"42", Integer, "42"},
+		{42, Integer, int32(42)},
+		{"42", Bigint, "42"},
+		{5, Smallint, 5},
+		{int64(5), Smallint, int16(5)},
+		{nil, Integer, nil},
+	}
+
+	for _, test := range tests {
+		p := valueParam("$1", test.value)
+		p.setInferredType(test.typ)
+
+		if p.Type() != test.typ {
+			t.Errorf("%T for %s - have type: %s", test.value, test.typ, p.Type())
+		}
+
+		if rat, ok := test.want.(*big.Rat); ok {
+			if have, ok := p.Value().(*big.Rat); !ok || have.Cmp(rat) != 0 {
+				t.Errorf("%T for %s - have: %v (%T), but want: %v", test.value, test.typ, p.Value(), p.Value(), rat)
+			}
+		} else if p.Value() != test.want {
+			t.Errorf("%T for %s - have: %v (%T), but want: %v (%T)",
+				test.value, test.typ, p.Value(), p.Value(), test.want, test.want)
+		}
+	}
+}
+
+func Test_Parameter_setInferredType_OutOfRange_ExpectError(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		typ   Type
+	}{
+		{5000000000, Integer},
+		{uint(math.MaxInt32 + 1), Integer},
+		{uint32(math.MaxUint32), Integer},
+		{uint64(math.MaxInt64 + 1), Bigint},
+		{uint16(math.MaxUint16), Smallint},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrValueOutOfRange) {
+					t.Errorf("%T %v for %s - have: %v, but want: %v", test.value, test.value, test.typ, err, ErrValueOutOfRange)
+				}
+			}()
+
+			valueParam("$1", test.value).setInferredType(test.typ)
+		}()
+	}
+}
+
+func Test_Parameter_SetValue_NumericNaN_ExpectError(t *testing.T) {
+	p := NewParameter("@amount", Numeric)
+
+	if err := p.SetValue(math.NaN()); err == nil {
+		t.Errorf("have: %v, but want an error", p.Value())
+	}
+
+	if err := p.SetValue((*big.Rat)(nil)); err != nil || p.Value() != nil {
+		t.Errorf("have: %v (err: %v), but want: nil", p.Value(), err)
+	}
+}
+
+func Test_Conn_writeBind_NilRat_SendsNull(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	conn := &Conn{writer: bufio.NewWriter(buf)}
+	stmt := &Statement{conn: conn, name: "s", portalName: "p"}
+	stmt.params = []*Parameter{{name: "$1", value: (*big.Rat)(nil)}}
+
+	conn.writeBind(stmt)
+	conn.writer.Flush()
+
+	// The parameter value is the last field before the result format codes.
+	msg := buf.Bytes()
+	if n := len(msg); n < 8 || !bytes.Equal(msg[n-8:n-4], []byte{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("expected a NULL parameter value, have: %v", msg)
+	}
+}
+
+func Test_Conn_writeBind_Bytes(t *testing.T) {
+	for _, test := range []struct {
+		typ  Type
+		want string
+	}{
+		{Type(_BYTEAOID), `\x005c41`},
+		{Custom, `\x005c41`},
+		{Text, "\x00\\A"},
+	} {
+		buf := bytes.NewBuffer(nil)
+		conn := &Conn{writer: bufio.NewWriter(buf)}
+		stmt := &Statement{conn: conn, name: "s", portalName: "p"}
+		stmt.params = []*Parameter{{name: "$1", typ: test.typ, value: []byte{0, '\\', 'A'}}}
+
+		conn.writeBind(stmt)
+		conn.writer.Flush()
+
+		// The parameter value precedes the result format codes.
+		msg := buf.Bytes()
+		if n := len(msg) - 4; n < len(test.want) || string(msg[n-len(test.want):n]) != test.want {
+			t.Errorf("%s - have: %q, but want the value %q", test.typ, msg, test.want)
+		}
+	}
+}
+
+func Test_Conn_Query_BytesRoundTrip(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		rs, err := conn.Query("SELECT length($1::bytea), encode($1::bytea, 'hex');", []byte{0, '\\', 0xff})
+		if err != nil {
+			t.Error("failed to query:", err)
+			return
+		}
+		defer rs.Close()
+
+		var length int
+		var encoded string
+		if _, err := rs.ScanNext(&length, &encoded); err != nil {
+			t.Error("failed to scan:", err)
+			return
+		}
+		if length != 3 || encoded != "005cff" {
+			t.Errorf("have: %d, %s, but want: 3, 005cff", length, encoded)
+		}
+	})
+}
+
+func Test_Conn_Query_PlainValuesForInferredTypes(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		rs, err := conn.Query("SELECT $1::numeric * 2, $2::float8 / 4, $3::int4 + 1, $4::int8 + 1, $5::int2 + 1;",
+			10, 5, "41", "9", 7)
+		if err != nil {
+			t.Error("failed to query:", err)
+			return
+		}
+		defer rs.Close()
+
+		var numeric string
+		var double float64
+		var integer int32
+		var bigint int64
+		var smallint int16
+
+		if _, err := rs.ScanNext(&numeric, &double, &integer, &bigint, &smallint); err != nil {
+			t.Error("failed to scan:", err)
+			return
+		}
+		if numeric != "20" || double != 1.25 || integer != 42 || bigint != 10 || smallint != 8 {
+			t.Errorf("have: %s, %v, %d, %d, %d, but want: 20, 1.25, 42, 10, 8",
+				numeric, double, integer, bigint, smallint)
+		}
+	})
+}
+
+func Test_Driver_ImplementsContextInterfaces(t *testing.T) {
+	var drv interface{} = sqlDriver{}
+	if _, ok := drv.(driver.DriverContext); !ok {
+		t.Error("sqlDriver does not implement driver.DriverContext")
+	}
+
+	var conn interface{} = &sqlConn{}
+	if _, ok := conn.(driver.ConnBeginTx); !ok {
+		t.Error("sqlConn does not implement driver.ConnBeginTx")
+	}
+	if _, ok := conn.(driver.ConnPrepareContext); !ok {
+		t.Error("sqlConn does not implement driver.ConnPrepareContext")
+	}
+	if _, ok := conn.(driver.ExecerContext); !ok {
+		t.Error("sqlConn does not implement driver.ExecerContext")
+	}
+	if _, ok := conn.(driver.QueryerContext); !ok {
+		t.Error("sqlConn does not implement driver.QueryerContext")
+	}
+	if _, ok := conn.(driver.Pinger); !ok {
+		t.Error("sqlConn does not implement driver.Pinger")
+	}
+
+	var stmt interface{} = &sqlStmt{}
+	if _, ok := stmt.(driver.StmtExecContext); !ok {
+		t.Error("sqlStmt does not implement driver.StmtExecContext")
+	}
+	if _, ok := stmt.(driver.StmtQueryContext); !ok {
+		t.Error("sqlStmt does not implement driver.StmtQueryContext")
+	}
+}
+
+func Test_sqlConnector_Connect_DSN_Canceled(t *testing.T) {
+	connector, err := sqlDriver{}.OpenConnector("dbname=testdatabase user=testuser password=testpassword")
+	if err != nil {
+		t.Fatal("OpenConnector:", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := connector.Connect(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("have: %v, but want: %v", err, context.Canceled)
+	}
+}
+
+func Test_Driver_ExecContext_Canceled(t *testing.T) {
+	db := sql.OpenDB(NewConnector(&ConnConfig{
+		Database: "testdatabase",
+		User:     "testuser",
+		Password: "testpassword",
+	}))
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "SELECT pg_sleep(10);"); err != context.DeadlineExceeded {
+		t.Errorf("have: %v, but want: %v", err, context.DeadlineExceeded)
+	}
+
+	var one int
+	if err := db.QueryRow("SELECT 1;").Scan(&one); err != nil || one != 1 {
+		t.Error("query after canceled query failed:", err)
+	}
+}
+
+func Test_Driver_QueryContext_Canceled(t *testing.T) {
+	db := sql.OpenDB(NewConnector(&ConnConfig{
+		Database: "testdatabase",
+		User:     "testuser",
+		Password: "testpassword",
+	}))
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+
+	// Depending on timing, the cancellation hits Query or fetching the row.
+	rows, err := db.QueryContext(ctx, "SELECT pg_sleep(10);")
+	if err == nil {
+		for rows.Next() {
+		}
+		err = rows.Err()
+		rows.Close()
+	}
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("have: %v, but want: %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("query was not canceled, it took %v", elapsed)
+	}
+
+	var one int
+	if err := db.QueryRow("SELECT 1;").Scan(&one); err != nil || one != 1 {
+		t.Error("query after canceled query failed:", err)
+	}
+}
+
+func Test_field_LengthAndPrecisionScale(t *testing.T) {
+	varchar20 := &field{typeOID: _VARCHAROID, typeModifier: 20 + 4}
+	if length, ok := varchar20.length(); !ok || length != 20 {
+		t.Errorf("varchar(20) - have: %d %t, but want: 20 true", length, ok)
+	}
+
+	text := &field{typeOID: _TEXTOID, typeModifier: -1}
+	if length, ok := text.length(); !ok || length != math.MaxInt64 {
+		t.Errorf("text - have: %d %t, but want: %d true", length, ok, int64(math.MaxInt64))
+	}
+
+	numeric10_2 := &field{typeOID: _NUMERICOID, typeModifier: 10<<16 | 2 + 4}
+	if precision, scale, ok := numeric10_2.precisionScale(); !ok || precision != 10 || scale != 2 {
+		t.Errorf("numeric(10,2) - have: %d %d %t, but want: 10 2 true", precision, scale, ok)
+	}
+
+	numeric := &field{typeOID: _NUMERICOID, typeModifier: -1}
+	if _, _, ok := numeric.precisionScale(); ok {
+		t.Error("numeric - expected ok == false")
+	}
+}
+
+func Test_Driver_ColumnTypes(t *testing.T) {
+	db, err := sql.Open("postgres", "dbname=testdatabase user=testuser password=testpassword")
+	if err != nil {
+		t.Error("failed to open database:", err)
+		return
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id, strreq, 1.5::numeric(4,2) FROM table1;")
+	if err != nil {
+		t.Error("failed to query:", err)
+		return
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		t.Error("failed to get column types:", err)
+		return
+	}
+
+	if name := types[0].DatabaseTypeName(); name != "INT4" {
+		t.Errorf("id - have: '%s', but want 'INT4'", name)
+	}
+	if scanType := types[0].ScanType(); scanType != reflect.TypeOf(int64(0)) {
+		t.Errorf("id - have scan type: %v, but want int64", scanType)
+	}
+	if length, ok := types[1].Length(); !ok || length != 20 {
+		t.Errorf("strreq - have: %d %t, but want: 20 true", length, ok)
+	}
+	if precision, scale, ok := types[2].DecimalSize(); !ok || precision != 4 || scale != 2 {
+		t.Errorf("numeric - have: %d %d %t, but want: 4 2 true", precision, scale, ok)
+	}
+}
+
+func Test_decodeBytea(t *testing.T) {
+	for text, want := range map[string][]byte{
+		`\x`:           {},
+		`\x005cff`:     {0, '\\', 0xff},
+		`a\\b\000\377`: {'a', '\\', 'b', 0, 0xff},
+	} {
+		if have := decodeBytea([]byte(text)); !bytes.Equal(have, want) {
+			t.Errorf("%s - have: %v, but want: %v", text, have, want)
+		}
+	}
+
+	for _, text := range []string{`\xzz`, `\12`, `\9999`} {
+		func() {
+			defer func() {
+				if x := recover(); x == nil {
+					t.Errorf("%s - expected panic", text)
+				}
+			}()
+
+			decodeBytea([]byte(text))
+		}()
+	}
+}
+
+func Test_ResultSet_Bytea(t *testing.T) {
+	want := []byte{0, '\\', 'A', 0xff}
+
+	withConn(t, func(conn *Conn) {
+		rs, err := conn.Query("SELECT $1::bytea;", want)
+		if err != nil {
+			t.Error("failed to query:", err)
+			return
+		}
+		defer rs.Close()
+
+		var have []byte
+		if _, err := rs.ScanNext(&have); err != nil || !bytes.Equal(have, want) {
+			t.Errorf("Scan - have: %v, but want: %v (err: %v)", have, want, err)
+		}
+
+		if value, _, err := rs.Any(0); err != nil || !bytes.Equal(value.([]byte), want) {
+			t.Errorf("Any - have: %v, but want: %v (err: %v)", value, want, err)
+		}
+	})
+
+	db, err := sql.Open("postgres", "dbname=testdatabase user=testuser password=testpassword")
+	if err != nil {
+		t.Error("failed to open database:", err)
+		return
+	}
+	defer db.Close()
+
+	var have []byte
+	if err := db.QueryRow("SELECT $1::bytea;", want).Scan(&have); err != nil || !bytes.Equal(have, want) {
+		t.Errorf("database/sql - have: %v, but want: %v (err: %v)", have, want, err)
+	}
+}
+
+func Test_Driver_NextResultSet(t *testing.T) {
+	db, err := sql.Open("postgres", "dbname=testdatabase user=testuser password=testpassword")
+	if err != nil {
+		t.Error("failed to open database:", err)
+		return
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT 1 AS a; SELECT 'two' AS b, 3 AS c;")
+	if err != nil {
+		t.Error("failed to query:", err)
+		return
+	}
+	defer rows.Close()
+
+	var a int
+	if !rows.Next() {
+		t.Error("expected a row in the first result set")
+		return
+	}
+	if err := rows.Scan(&a); err != nil || a != 1 {
+		t.Errorf("first result set - have: %d, but want: 1 (err: %v)", a, err)
+	}
+
+	if !rows.NextResultSet() {
+		t.Error("expected a second result set:", rows.Err())
+		return
+	}
+
+	if columns, _ := rows.Columns(); len(columns) != 2 || columns[0] != "b" {
+		t.Errorf("second result set - have columns: %v, but want: [b c]", columns)
+	}
+
+	var b string
+	var c int
+	if !rows.Next() {
+		t.Error("expected a row in the second result set")
+		return
+	}
+	if err := rows.Scan(&b, &c); err != nil || b != "two" || c != 3 {
+		t.Errorf("second result set - have: '%s' %d, but want: 'two' 3 (err: %v)", b, c, err)
+	}
+
+	if rows.NextResultSet() {
+		t.Error("expected no third result set")
+	}
+}
+
+func Test_Driver_BrokenConn_ExpectErrBadConn(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		c := &sqlConn{conn}
+
+		conn.tcpConn.Close()
+
+		_, err := c.ExecContext(context.Background(), "SELECT 1;", nil)
+		if err != driver.ErrBadConn {
+			t.Errorf("have: %v, but want: %v", err, driver.ErrBadConn)
+		}
+
+		if c.IsValid() {
+			t.Error("broken connection reported as valid")
+		}
+
+		if err := c.ResetSession(context.Background()); err != driver.ErrBadConn {
+			t.Errorf("ResetSession - have: %v, but want: %v", err, driver.ErrBadConn)
+		}
+	})
+}
+
+func Test_badConnErr_OnlyIfNothingWasSent(t *testing.T) {
+	ioErr := errors.New("connection reset by peer")
+	conn := &Conn{state: disconnectedState{}, flushes: 3}
+
+	if err := badConnErr(conn, 3, ioErr); err != driver.ErrBadConn {
+		t.Errorf("write failed - have: %v, but want: %v", err, driver.ErrBadConn)
+	}
+
+	if err := badConnErr(conn, 2, ioErr); err != ioErr {
+		t.Errorf("read failed after flush - have: %v, but want: %v", err, ioErr)
+	}
+
+	conn.state = readyState{}
+	if err := badConnErr(conn, 3, ioErr); err != ioErr {
+		t.Errorf("connection still usable - have: %v, but want: %v", err, ioErr)
+	}
+}
+
+func Test_Driver_ResetSession_DiscardAll(t *testing.T) {
+	db := sql.OpenDB(NewConnector(&ConnConfig{
+		Database:          "testdatabase",
+		User:              "testuser",
+		Password:          "testpassword",
+		DiscardAllOnReset: true,
+	}))
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	stmt, err := db.Prepare("SELECT $1::int;")
+	if err != nil {
+		t.Error("failed to prepare:", err)
+		return
+	}
+	defer stmt.Close()
+
+	if _, err := db.Exec("SET application_name = 'reset_test';"); err != nil {
+		t.Error("failed to set application_name:", err)
+		return
+	}
+
+	var name string
+	if err := db.QueryRow("SHOW application_name;").Scan(&name); err != nil || name == "reset_test" {
+		t.Errorf("application_name not reset - have: '%s' (err: %v)", name, err)
+	}
+
+	// The statement was dropped by DISCARD ALL and must be prepared again.
+	var n int
+	if err := stmt.QueryRow(42).Scan(&n); err != nil || n != 42 {
+		t.Errorf("have: %d, but want: 42 (err: %v)", n, err)
+	}
+}
+
+func Test_Driver_CheckNamedValue(t *testing.T) {
+	c := &sqlConn{}
+
+	for _, v := range []interface{}{[]int64{1}, []string{"a"}, json.RawMessage(`{}`), net.IPv4(127, 0, 0, 1), time.Second, big.NewRat(1, 2), [16]byte{}} {
+		if err := c.CheckNamedValue(&driver.NamedValue{Value: v}); err != nil {
+			t.Errorf("%T - have: %v, but want: nil", v, err)
+		}
+	}
+
+	if err := c.CheckNamedValue(&driver.NamedValue{Value: struct{}{}}); err != driver.ErrSkip {
+		t.Errorf("struct{} - have: %v, but want: %v", err, driver.ErrSkip)
+	}
+}
+
+func Test_argsFromNamedValues_Mixed_ExpectError(t *testing.T) {
+	_, err := argsFromNamedValues([]driver.NamedValue{{Name: "a", Ordinal: 1, Value: 1}, {Ordinal: 2, Value: 2}})
+	if err == nil {
+		t.Error("expected an error for mixed named and positional arguments")
+	}
+}
+
+func Test_Driver_ExtendedArgumentTypes(t *testing.T) {
+	db, err := sql.Open("postgres", "dbname=testdatabase user=testuser password=testpassword")
+	if err != nil {
+		t.Error("failed to open database:", err)
+		return
+	}
+	defer db.Close()
+
+	var ints, strs, ip, interval, uuid, js string
+	err = db.QueryRow("SELECT $1::int8[]::text, $2::text[]::text, $3::inet::text, $4::interval::text, $5::uuid::text, $6::jsonb::text;",
+		[]int64{1, 2}, []string{`a"b`, `c\d`}, net.IPv4(10, 0, 0, 1), 90*time.Second,
+		[16]byte{0xa0, 0xee, 0xbc, 0x99, 0x9c, 0x0b, 0x4e, 0xf8, 0xbb, 0x6d, 0x6b, 0xb9, 0xbd, 0x38, 0x0a, 0x11},
+		json.RawMessage(`{"a": 1}`)).Scan(&ints, &strs, &ip, &interval, &uuid, &js)
+	if err != nil {
+		t.Error("failed to query:", err)
+		return
+	}
+
+	want := []string{"{1,2}", `{"a\"b","c\\d"}`, "10.0.0.1", "00:01:30", "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", `{"a": 1}`}
+	for i, have := range []string{ints, strs, ip, interval, uuid, js} {
+		if have != want[i] {
+			t.Errorf("column %d - have: %s, but want: %s", i, have, want[i])
+		}
+	}
+
+	var isNull bool
+	if err := db.QueryRow("SELECT @v::int IS NULL;", sql.Named("v", nil)).Scan(&isNull); err != nil || !isNull {
+		t.Errorf("have: %t, but want: true (err: %v)", isNull, err)
+	}
+
+	var n int64
+	if err := db.QueryRow("SELECT :n + 1;", sql.Named("n", sql.NullInt64{Int64: 41, Valid: true})).Scan(&n); err != nil || n != 42 {
+		t.Errorf("have: %d, but want: 42 (err: %v)", n, err)
+	}
+}
+
+func Test_ResultSet_Scan_NullableDestinations(t *testing.T) {
+	rs := newResultSet(&Conn{})
+	rs.setFields([]field{{name: "s", typeOID: _TEXTOID}, {name: "n", typeOID: _INT8OID}, {name: "r", typeOID: _NUMERICOID}})
+	rs.hasCurrentRow = true
+
+	rs.values = [][]byte{nil, nil, []byte("1.50")}
+
+	s, n := new(string), new(int64)
+	var ns sql.NullString
+	var nf sql.NullFloat64
+	if err := rs.Scan(&s, &n, &nf); err != nil {
+		t.Error("failed to scan:", err)
+		return
+	}
+	if s != nil || n != nil {
+		t.Errorf("have: %v %v, but want: <nil> <nil>", s, n)
+	}
+	if !nf.Valid || nf.Float64 != 1.5 {
+		t.Errorf("have: %v, but want: {1.5 true}", nf)
+	}
+
+	rs.values = [][]byte{[]byte("abc"), []byte("42"), nil}
+
+	if err := rs.Scan(&s, &n, &ns); err != nil {
+		t.Error("failed to scan:", err)
+		return
+	}
+	if s == nil || *s != "abc" || n == nil || *n != 42 {
+		t.Errorf("have: %v %v, but want: abc 42", s, n)
+	}
+	if ns.Valid {
+		t.Errorf("have: %v, but want an invalid sql.NullString", ns)
+	}
+
+	var unsupported struct{}
+	if err := rs.Scan(&s, &n, &unsupported); err == nil {
+		t.Error("expected an error for an unsupported destination type")
+	}
+}
+
+func Test_snakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"ID":         "id",
+		"Name":       "name",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"UnitPrice2": "unit_price2",
+	} {
+		if have := snakeCase(name); have != want {
+			t.Errorf("%s - have: %s, but want: %s", name, have, want)
+		}
+	}
+}
+
+type structScanAudit struct {
+	CreatedBy string
+	Name      string // Shadowed by structScanItem.Name
+}
+
+type structScanItem struct {
+	ID     int64 `pgsql:"item_id"`
+	Name   string
+	Price  *float64
+	secret string
+	Ignore string `pgsql:"-"`
+	*structScanAudit
+}
+
+func Test_column2fieldIndex(t *testing.T) {
+	have := column2fieldIndex(reflect.TypeOf(structScanItem{}))
+	want := map[string][]int{
+		"item_id":    {0},
+		"name":       {1},
+		"price":      {2},
+		"created_by": {5, 0},
+	}
+
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("have: %v, but want: %v", have, want)
+	}
+}
+
+func Test_Conn_SelectAndGet(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		var items []structScanItem
+		err := conn.Select(&items, "SELECT 1 AS item_id, 'a' AS name, NULL::float8 AS price, 'x' AS created_by UNION ALL SELECT 2, 'b', 2.5, 'y' ORDER BY 1;")
+		if err != nil {
+			t.Error("Select failed:", err)
+			return
+		}
+
+		if len(items) != 2 || items[0].ID != 1 || items[0].Price != nil || items[1].Name != "b" ||
+			items[1].Price == nil || *items[1].Price != 2.5 || items[1].CreatedBy != "y" {
+			t.Errorf("unexpected items: %+v", items)
+		}
+
+		var item structScanItem
+		fetched, err := conn.Get(&item, "SELECT 3 AS item_id, 'c' AS name, 'unmapped' AS other;")
+		if err != nil || !fetched || item.ID != 3 || item.Name != "c" {
+			t.Errorf("have: %+v %t, but want: item 3 (err: %v)", item, fetched, err)
+		}
+
+		conn.StrictStructScan = true
+		if _, err := conn.Get(&item, "SELECT 3 AS item_id, 'unmapped' AS other;"); err == nil {
+			t.Error("expected an error for an unmapped column in strict mode")
+		}
+	})
+}
+
+func scanInt(rs *ResultSet) (n int, err error) {
+	err = rs.Scan(&n)
+	return
+}
+
+func Test_Rows_BreakClosesResultSet(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		rs, err := conn.Query("SELECT generate_series(1, 10);")
+		if err != nil {
+			t.Error("failed to query:", err)
+			return
+		}
+
+		var sum int
+		for n, err := range Rows(rs, scanInt) {
+			if err != nil {
+				t.Error("failed to scan:", err)
+				return
+			}
+			if n > 3 {
+				break
+			}
+			sum += n
+		}
+
+		if sum != 6 {
+			t.Errorf("have: %d, but want: 6", sum)
+		}
+
+		if status := conn.Status(); status != StatusReady {
+			t.Errorf("status - have: %s, but want: %s", status, StatusReady)
+		}
+	})
+}
+
+func Test_CollectRows(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		rs, err := conn.Query("SELECT generate_series(1, 3);")
+		if err != nil {
+			t.Error("failed to query:", err)
+			return
+		}
+
+		if ns, err := CollectRows(rs, scanInt); err != nil || !reflect.DeepEqual(ns, []int{1, 2, 3}) {
+			t.Errorf("have: %v, but want: [1 2 3] (err: %v)", ns, err)
+		}
+
+		rs, err = conn.Query("SELECT 1 WHERE false;")
+		if err != nil {
+			t.Error("failed to query:", err)
+			return
+		}
+
+		if _, err := CollectOneRow(rs, scanInt); err != ErrNoRows {
+			t.Errorf("have: %v, but want: %v", err, ErrNoRows)
+		}
+	})
+}
+
+func Test_ResultSet_AccessByName(t *testing.T) {
+	rs := newResultSet(&Conn{})
+	rs.setFields([]field{{name: "id", typeOID: _INT8OID}, {name: "name", typeOID: _TEXTOID}, {name: "note", typeOID: _TEXTOID}})
+	rs.hasCurrentRow = true
+	rs.values = [][]byte{[]byte("7"), []byte("abc"), nil}
+
+	if name, isNull, err := Get[string](rs, "name"); err != nil || isNull || name != "abc" {
+		t.Errorf("name - have: '%s' %t, but want: 'abc' false (err: %v)", name, isNull, err)
+	}
+
+	if note, isNull, err := Get[*string](rs, "note"); err != nil || !isNull || note != nil {
+		t.Errorf("note - have: %v %t, but want: <nil> true (err: %v)", note, isNull, err)
+	}
+
+	if _, _, err := Get[int](rs, "missing"); err == nil {
+		t.Error("expected an error for an unknown field name")
+	}
+
+	values, err := rs.Values()
+	if err != nil || !reflect.DeepEqual(values, []interface{}{int64(7), "abc", nil}) {
+		t.Errorf("Values - have: %v, but want: [7 abc <nil>] (err: %v)", values, err)
+	}
+
+	m, err := rs.Map()
+	if err != nil || !reflect.DeepEqual(m, map[string]interface{}{"id": int64(7), "name": "abc", "note": nil}) {
+		t.Errorf("Map - have: %v (err: %v)", m, err)
+	}
+
+	rs.setFields([]field{{name: "id", typeOID: _INT8OID}, {name: "id", typeOID: _INT8OID}})
+	rs.hasCurrentRow = true
+	rs.values = [][]byte{[]byte("1"), []byte("2")}
+
+	if ord := rs.Ordinal("id"); ord != 0 {
+		t.Errorf("Ordinal - have: %d, but want: 0", ord)
+	}
+
+	if _, _, err := Get[int64](rs, "id"); err == nil {
+		t.Error("Get - expected an error for an ambiguous field name")
+	}
+
+	if _, err := rs.Map(); err == nil {
+		t.Error("Map - expected an error for duplicate field names")
+	}
+}
+
+func Test_ResultSet_Fields(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		if _, err := conn.Execute("CREATE TEMP TABLE fields_test (id int8, code varchar(20), amount numeric(10, 2));"); err != nil {
+			t.Error("failed to create table:", err)
+			return
+		}
+
+		rs, err := conn.Query("SELECT amount, code, id, 1 AS one FROM fields_test;")
+		if err != nil {
+			t.Error("failed to query:", err)
+			return
+		}
+		defer rs.Close()
+
+		fields := rs.Fields()
+		if len(fields) != 4 {
+			t.Errorf("have %d fields, but want 4", len(fields))
+			return
+		}
+
+		if p, s, ok := fields[0].PrecisionScale(); !ok || p != 10 || s != 2 {
+			t.Errorf("amount - have: %d %d %t, but want: 10 2 true", p, s, ok)
+		}
+		if l, ok := fields[1].Length(); !ok || l != 20 || fields[1].TypeName != "VARCHAR" {
+			t.Errorf("code - have: %d %t %s, but want: 20 true VARCHAR", l, ok, fields[1].TypeName)
+		}
+		if f := fields[2]; f.TableOID == 0 || f.AttNum != 1 || f.TypeOID != _INT8OID || f.TypeSize != 8 {
+			t.Errorf("id - have: %+v", f)
+		}
+		if f := fields[3]; f.TableOID != 0 || f.AttNum != 0 || f.Name != "one" {
+			t.Errorf("one - have: %+v", f)
+		}
+	})
+}
+
+func Test_parseCommandTag(t *testing.T) {
+	for text, want := range map[string]CommandTag{
+		"INSERT 0 5":          {Command: "INSERT", RowsAffected: 5},
+		"INSERT 123 1":        {Command: "INSERT", RowsAffected: 1, InsertOID: 123},
+		"INSERT 4294967295 1": {Command: "INSERT", RowsAffected: 1, InsertOID: 4294967295},
+		"SELECT 3":            {Command: "SELECT", RowsAffected: 3},
+		"MERGE 2":             {Command: "MERGE", RowsAffected: 2},
+		"CREATE TABLE":        {Command: "CREATE TABLE"},
+	} {
+		want.text = text
+
+		if have := parseCommandTag(text); have != want {
+			t.Errorf("%s - have: %+v, but want: %+v", text, have, want)
+		}
+	}
+}
+
+func Test_Conn_ExecuteTag(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		tag, err := conn.ExecuteTag("CREATE TEMP TABLE tag_test (id int);")
+		if err != nil || tag.Command != "CREATE TABLE" {
+			t.Errorf("have: %+v, but want: CREATE TABLE (err: %v)", tag, err)
+		}
+
+		tag, err = conn.ExecuteTag("INSERT INTO tag_test SELECT generate_series(1, $1::int);", 5)
+		if err != nil || tag.Command != "INSERT" || tag.RowsAffected != 5 {
+			t.Errorf("have: %+v, but want: INSERT 0 5 (err: %v)", tag, err)
+		}
+
+		rs, err := conn.Query("SELECT * FROM tag_test; DELETE FROM tag_test WHERE id > 3;")
+		if err != nil {
+			t.Error("failed to query:", err)
+			return
+		}
+		defer rs.Close()
+
+		for {
+			hasRow, err := rs.FetchNext()
+			if err != nil || !hasRow {
+				break
+			}
+		}
+
+		if tag := rs.CommandTag(); tag.String() != "SELECT 5" {
+			t.Errorf("first result - have: %s, but want: SELECT 5", tag)
+		}
+
+		if hasResult, err := rs.NextResult(); err != nil || !hasResult {
+			t.Error("expected a second result:", err)
+			return
+		}
+
+		if tag := rs.CommandTag(); tag.String() != "DELETE 2" {
+			t.Errorf("second result - have: %s, but want: DELETE 2", tag)
+		}
+	})
+}
+
+func Test_TxOptions_beginCommand(t *testing.T) {
+	for _, test := range []struct {
+		opts TxOptions
+		want string
+	}{
+		{TxOptions{}, "BEGIN ISOLATION LEVEL READ COMMITTED;"},
+		{TxOptions{Isolation: DefaultIsolation}, "BEGIN;"},
+		{TxOptions{Isolation: RepeatableReadIsolation, AccessMode: ReadWriteAccess}, "BEGIN ISOLATION LEVEL REPEATABLE READ, READ WRITE;"},
+		{TxOptions{Isolation: SerializableIsolation, AccessMode: ReadOnlyAccess, Deferrable: true}, "BEGIN ISOLATION LEVEL SERIALIZABLE, READ ONLY, DEFERRABLE;"},
+		{TxOptions{Isolation: ReadUncommittedIsolation}, "BEGIN ISOLATION LEVEL READ UNCOMMITTED;"},
+	} {
+		if have := test.opts.beginCommand(); have != test.want {
+			t.Errorf("have: %s, but want: %s", have, test.want)
+		}
+	}
+}
+
+func Test_Conn_WithTransactionOptions_ReadOnly(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		opts := TxOptions{Isolation: RepeatableReadIsolation, AccessMode: ReadOnlyAccess}
+
+		err := conn.WithTransactionOptions(opts, func() error {
+			var isolation, readOnly string
+			if _, err := conn.Scan("SHOW transaction_isolation;", &isolation); err != nil {
+				return err
+			}
+			if _, err := conn.Scan("SHOW transaction_read_only;", &readOnly); err != nil {
+				return err
+			}
+
+			if isolation != "repeatable read" || readOnly != "on" {
+				t.Errorf("have: %s %s, but want: repeatable read on", isolation, readOnly)
+			}
+
+			_, err := conn.Execute("CREATE TABLE read_only_test (id int);")
+			return err
+		})
+		if err == nil {
+			t.Error("expected an error creating a table in a read only transaction")
+		}
+	})
+}
+
+func Test_Tx_CommitRollbackAndSavepoints(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		if _, err := conn.Execute("CREATE TEMP TABLE tx_test (id int);"); err != nil {
+			t.Error("failed to create table:", err)
+			return
+		}
+
+		tx, err := conn.Begin(TxOptions{})
+		if err != nil {
+			t.Error("failed to begin:", err)
+			return
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Execute("INSERT INTO tx_test VALUES (1);"); err != nil {
+			t.Error("failed to insert:", err)
+			return
+		}
+
+		outer, err := tx.Savepoint()
+		if err != nil {
+			t.Error("failed to create savepoint:", err)
+			return
+		}
+
+		inner, err := tx.Savepoint()
+		if err != nil {
+			t.Error("failed to create nested savepoint:", err)
+			return
+		}
+
+		// A failing command, which RollbackTo recovers from.
+		tx.Execute("INSERT INTO tx_test VALUES ('x');")
+
+		if err := outer.RollbackTo(); err != nil {
+			t.Error("failed to roll back to savepoint:", err)
+		}
+		if err := inner.Release(); !errors.Is(err, ErrSavepointDone) {
+			t.Errorf("nested savepoint - have: %v, but want: %v", err, ErrSavepointDone)
+		}
+		if err := outer.Release(); err != nil {
+			t.Error("failed to release savepoint:", err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			t.Error("failed to commit:", err)
+		}
+
+		if _, err := tx.Execute("INSERT INTO tx_test VALUES (2);"); !errors.Is(err, ErrTxDone) {
+			t.Errorf("after commit - have: %v, but want: %v", err, ErrTxDone)
+		}
+		if err := tx.Rollback(); err != nil {
+			t.Error("rollback after commit should be a no-op:", err)
+		}
+
+		var count int
+		if _, err := conn.Scan("SELECT count(*) FROM tx_test;", &count); err != nil || count != 1 {
+			t.Errorf("have: %d, but want: 1 (err: %v)", count, err)
+		}
+	})
+}
+
+func Test_ExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 100*time.Millisecond)
+
+	for attempt, max := range map[int]time.Duration{1: 10 * time.Millisecond, 3: 40 * time.Millisecond, 10: 100 * time.Millisecond, 100: 100 * time.Millisecond} {
+		if delay := backoff(attempt); delay < max/2 || delay > max {
+			t.Errorf("attempt %d - have: %s, but want: %s to %s", attempt, delay, max/2, max)
+		}
+	}
+
+	// Large bases and many attempts must not overflow.
+	backoff = ExponentialBackoff(time.Hour, 100*time.Hour)
+	for _, attempt := range []int{1, 8, 30, 64, 1000} {
+		if delay := backoff(attempt); delay <= 0 || delay > 100*time.Hour {
+			t.Errorf("attempt %d - have: %s, but want: up to %s", attempt, delay, 100*time.Hour)
+		}
+	}
+
+	backoff = ExponentialBackoff(3*time.Second, 10*time.Second)
+	if delay := backoff(3); delay < 5*time.Second || delay > 10*time.Second {
+		t.Errorf("have: %s, but want: %s to %s", delay, 5*time.Second, 10*time.Second)
+	}
+}
+
+func Test_Conn_RunInTransaction_RetriesSerializationFailures(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		policy := RetryPolicy{MaxAttempts: 3}
+
+		var attempts int
+		err := conn.RunInTransaction(TxOptions{Isolation: SerializableIsolation}, policy, func(tx *Tx) error {
+			attempts++
+			if attempts < 3 {
+				return &Error{code: "40001", message: "could not serialize access"}
+			}
+
+			_, err := tx.Execute("SELECT 1;")
+			return err
+		})
+		if err != nil || attempts != 3 {
+			t.Errorf("have: %d attempts, but want: 3 (err: %v)", attempts, err)
+		}
+
+		attempts = 0
+		err = conn.RunInTransaction(TxOptions{}, policy, func(tx *Tx) error {
+			attempts++
+			return &Error{code: "40P01", message: "deadlock detected"}
+		})
+
+		var retryErr *TxRetryError
+		if !errors.As(err, &retryErr) || retryErr.Attempts != 3 || attempts != 3 {
+			t.Errorf("have: %v after %d attempts, but want a *TxRetryError after 3 attempts", err, attempts)
+		}
+
+		attempts = 0
+		conn.RunInTransaction(TxOptions{}, policy, func(tx *Tx) error {
+			attempts++
+			return errors.New("not retryable")
+		})
+		if attempts != 1 {
+			t.Errorf("have: %d attempts, but want: 1 for an error that is not retryable", attempts)
+		}
+	})
+}
+
+func Test_quoteLiteral(t *testing.T) {
+	for s, want := range map[string]string{
+		"gid-1": "'gid-1'",
+		"it's":  "'it''s'",
+		`a\b'c`: `E'a\\b''c'`,
+		"":      "''",
+	} {
+		if have := quoteLiteral(s); have != want {
+			t.Errorf("%s - have: %s, but want: %s", s, have, want)
+		}
+	}
+}
+
+func Test_Tx_PrepareTransaction(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		var maxPrepared int
+		if _, err := conn.Scan("SELECT current_setting('max_prepared_transactions')::int;", &maxPrepared); err != nil || maxPrepared == 0 {
+			t.Skip("max_prepared_transactions is 0")
+		}
+
+		tx, err := conn.Begin(TxOptions{})
+		if err != nil {
+			t.Error("failed to begin:", err)
+			return
+		}
+
+		if _, err := tx.Execute("SELECT 1;"); err != nil {
+			t.Error("failed to execute:", err)
+			return
+		}
+
+		if err := tx.PrepareTransaction("pgsql-test-gid"); err != nil {
+			t.Error("failed to prepare transaction:", err)
+			return
+		}
+
+		xacts, err := conn.PreparedTransactions()
+		if err != nil {
+			t.Error("failed to list prepared transactions:", err)
+		}
+
+		var found bool
+		for _, xact := range xacts {
+			found = found || xact.GID == "pgsql-test-gid"
+		}
+		if !found {
+			t.Errorf("prepared transaction not listed: %+v", xacts)
+		}
+
+		if err := conn.RollbackPrepared("pgsql-test-gid"); err != nil {
+			t.Error("failed to roll back prepared transaction:", err)
+		}
+
+		tx, err = conn.Begin(TxOptions{})
+		if err != nil {
+			t.Error("failed to begin:", err)
+			return
+		}
+		defer tx.Rollback()
+
+		tx.Execute("SELECT 1/0;")
+
+		if err := tx.PrepareTransaction("pgsql-test-failed"); err == nil {
+			t.Error("expected an error preparing a failed transaction")
+		}
+	})
+}
+
+func Test_Error_IsSQLState(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &Error{code: "23505"})
+
+	if !errors.Is(err, UniqueViolation) || !IsUniqueViolation(err) {
+		t.Error("expected a unique violation")
+	}
+	if !errors.Is(err, IntegrityConstraintViolation) {
+		t.Error("expected a match for the class code")
+	}
+	if errors.Is(err, ForeignKeyViolation) || IsSerializationFailure(err) {
+		t.Error("unexpected match for another code")
+	}
+
+	var pgErr *Error
+	if !errors.As(err, &pgErr) || pgErr.Class() != "23" || pgErr.SQLState() != UniqueViolation {
+		t.Errorf("have: %v, but want class 23, code 23505", pgErr)
+	}
+}
+
+func Test_Error_ObjectFields(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		if _, err := conn.Execute("CREATE TEMP TABLE error_fields (id int CONSTRAINT error_fields_pk PRIMARY KEY, name text NOT NULL);"); err != nil {
+			t.Error("failed to create table:", err)
+			return
+		}
+
+		if _, err := conn.Execute("INSERT INTO error_fields VALUES (1, 'a');"); err != nil {
+			t.Error("failed to insert:", err)
+			return
+		}
+
+		_, err := conn.Execute("INSERT INTO error_fields VALUES (1, 'b');")
+
+		var pgErr *Error
+		if !errors.As(err, &pgErr) {
+			t.Errorf("have: %v, but want: *Error", err)
+			return
+		}
+		if pgErr.TableName() != "error_fields" || pgErr.ConstraintName() != "error_fields_pk" ||
+			pgErr.SchemaName() == "" || pgErr.SeverityNonLocalized() != "ERROR" {
+			t.Errorf("unique violation - have: schema %q, table %q, constraint %q, severity %q",
+				pgErr.SchemaName(), pgErr.TableName(), pgErr.ConstraintName(), pgErr.SeverityNonLocalized())
+		}
+
+		_, err = conn.Execute("INSERT INTO error_fields VALUES (2, NULL);")
+		if !errors.As(err, &pgErr) || pgErr.ColumnName() != "name" {
+			t.Errorf("not null violation - have: %v, but want column name", err)
+		}
+	})
+}
+
+func Test_convertPanic(t *testing.T) {
+	err := convertPanic("*ResultSet.Scan", &ArgumentCountError{Have: 1, Want: 2})
+
+	var opErr *OpError
+	if !errors.As(err, &opErr) || opErr.Op != "*ResultSet.Scan" {
+		t.Errorf("have: %v, but want: *OpError for *ResultSet.Scan", err)
+	}
+
+	var countErr *ArgumentCountError
+	if !errors.As(err, &countErr) || countErr.Have != 1 || countErr.Want != 2 {
+		t.Errorf("have: %v, but want: *ArgumentCountError", err)
+	}
+
+	if again := convertPanic("*Conn.WithTransaction", err); again != err {
+		t.Errorf("have: %v, but want the *OpError unchanged: %v", again, err)
+	}
+
+	if err := convertPanic("*Conn.Query", ErrConnClosed); !errors.Is(err, ErrConnClosed) {
+		t.Errorf("have: %v, but want: %v", err, ErrConnClosed)
+	}
+
+	if err := convertPanic("*Conn.Query", "oops"); err.Error() != "*Conn.Query: oops" {
+		t.Errorf("have: %q, but want: %q", err, "*Conn.Query: oops")
+	}
+}
+
+func Test_Conn_SetStatementCacheCapacity_Negative_ExpectOpError(t *testing.T) {
+	conn := &Conn{state: disconnectedState{}}
+
+	err := conn.SetStatementCacheCapacity(-1)
+
+	var opErr *OpError
+	if !errors.As(err, &opErr) || opErr.Op != "*Conn.SetStatementCacheCapacity" ||
+		opErr.Err.Error() != "capacity must be >= 0" {
+		t.Errorf("have: %v, but want: *OpError for *Conn.SetStatementCacheCapacity", err)
+	}
+}
+
+func Test_Conn_Closed_ExpectErrConnClosed(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		if err := conn.Close(); err != nil {
+			t.Error("failed to close:", err)
+			return
+		}
+
+		if _, err := conn.Execute("SELECT 1;"); !errors.Is(err, ErrConnClosed) {
+			t.Errorf("have: %v, but want: %v", err, ErrConnClosed)
+		}
+	})
+}
+
+func Test_ResultSet_NoCurrentRow_ExpectErrNoCurrentRow(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		rs, err := conn.Query("SELECT 1;")
+		if err != nil {
+			t.Error("failed to query:", err)
+			return
+		}
+		defer rs.Close()
+
+		var i int
+		if err := rs.Scan(&i); !errors.Is(err, ErrNoCurrentRow) {
+			t.Errorf("have: %v, but want: %v", err, ErrNoCurrentRow)
+		}
+
+		if _, err := rs.FetchNext(); err != nil {
+			t.Error("failed to fetch:", err)
+			return
+		}
+
+		var countErr *ArgumentCountError
+		if err := rs.Scan(&i, &i); !errors.As(err, &countErr) {
+			t.Errorf("have: %v, but want: *ArgumentCountError", err)
+		}
+	})
+}
+
+func Test_Error_setCommand_MapsPosition(t *testing.T) {
+	params := []*Parameter{NewParameter("@id", Integer), NewCustomTypeParameter("@mood", "mood")}
+	command := "SELECT @mood, 'ä', @id FORM t;"
+
+	actual, rewrites := rewriteCommand(command, params, false)
+	if want := "SELECT $2::mood, 'ä', $1 FORM t;"; actual != want {
+		t.Fatalf("have: '%s', but want '%s'", actual, want)
+	}
+
+	tests := []struct {
+		actualPosition string
+		want           string
+	}{
+		{"1", "1"},   // SELECT
+		{"8", "8"},   // $2::mood
+		{"12", "8"},  // ::mood
+		{"18", "15"}, // 'ä'
+		{"23", "20"}, // $1
+		{"26", "24"}, // FORM
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		err := &Error{position: test.actualPosition}
+		err.setCommand(command, actual, rewrites)
+
+		if err.Position() != test.want || err.ActualPosition() != test.actualPosition {
+			t.Errorf("position %s - have: %s (actual: %s), but want: %s",
+				test.actualPosition, err.Position(), err.ActualPosition(), test.want)
+		}
+	}
+}
+
+func Test_FormatError(t *testing.T) {
+	err := &Error{
+		severity: "ERROR",
+		message:  `syntax error at or near "FORM"`,
+		position: "19",
+		hint:     "Check the spelling.",
+	}
+	err.setCommand("SELECT *\n\tFROM t\n\tFORM u;", "SELECT *\n\tFROM t\n\tFORM u;", nil)
+
+	want := "ERROR:  syntax error at or near \"FORM\"\n" +
+		"LINE 3: \tFORM u;\n" +
+		"        \t^\n" +
+		"HINT:  Check the spelling."
+
+	if have := FormatError(fmt.Errorf("wrapped: %w", err)); have != want {
+		t.Errorf("have:\n%s\nbut want:\n%s", have, want)
+	}
+
+	if have := FormatError(ErrConnClosed); have != ErrConnClosed.Error() {
+		t.Errorf("have: %s, but want: %s", have, ErrConnClosed)
+	}
+}
+
+func Test_Conn_Query_ErrorPositionInOriginalCommand(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		command := "SELECT @id, no_such_column;"
+
+		_, err := conn.Query(command, idParameter(1))
+
+		var pgErr *Error
+		if !errors.As(err, &pgErr) {
+			t.Errorf("have: %v, but want: *Error", err)
+			return
+		}
+		if pgErr.Command() != command || pgErr.Position() != "13" || pgErr.ActualPosition() != "12" {
+			t.Errorf("have: command '%s', position %s (actual: %s), but want position 13 (actual: 12)",
+				pgErr.Command(), pgErr.Position(), pgErr.ActualPosition())
+		}
+	})
+}
+
+func Test_Conn_OnNotice(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		var notices []*Notice
+		conn.OnNotice(func(notice *Notice) {
+			notices = append(notices, notice)
+		})
+
+		if _, err := conn.Execute(`DO $$ BEGIN RAISE NOTICE 'hello %', 42 USING HINT = 'greeting'; END $$;`); err != nil {
+			t.Error("failed to execute:", err)
+			return
+		}
+
+		if len(notices) != 1 {
+			t.Errorf("have: %d notices, but want: 1", len(notices))
+			return
+		}
+		if n := notices[0]; n.Message() != "hello 42" || n.Hint() != "greeting" ||
+			n.SeverityNonLocalized() != "NOTICE" || n.SQLState() != "00000" {
+			t.Errorf("have: %v, but want: NOTICE 'hello 42'", n)
+		}
+
+		if _, err := conn.Execute("CREATE TEMP TABLE notice_test (id int);"); err != nil {
+			t.Error("failed to create table:", err)
+			return
+		}
+		if _, err := conn.Execute(`CREATE FUNCTION pg_temp.warn() RETURNS int LANGUAGE plpgsql AS $$ BEGIN RAISE WARNING 'deprecated'; RETURN 1; END $$;`); err != nil {
+			t.Error("failed to create function:", err)
+			return
+		}
+
+		notices = nil
+		if _, err := conn.Execute("INSERT INTO notice_test SELECT pg_temp.warn();"); err != nil {
+			t.Error("failed to insert:", err)
+			return
+		}
+		if len(notices) != 1 || notices[0].SeverityNonLocalized() != "WARNING" || notices[0].Message() != "deprecated" {
+			t.Errorf("have: %v, but want: WARNING 'deprecated'", notices)
+		}
+	})
+}
+
+func Test_Conn_CopyFrom_ErrorRefersToCopyCommand(t *testing.T) {
+	withConn(t, func(conn *Conn) {
+		rs, err := conn.Query("SELECT @id;", idParameter(1))
+		if err != nil {
+			t.Error("failed to query:", err)
+			return
+		}
+		rs.Close()
+
+		command := "COPY table1 (no_such_column) FROM STDIN;"
+
+		_, err = conn.CopyFrom(command, bytes.NewBufferString(""))
+
+		var pgErr *Error
+		if !errors.As(err, &pgErr) {
+			t.Errorf("have: %v, but want: *Error", err)
+			return
+		}
+		if pgErr.Command() != command || pgErr.Position() != pgErr.ActualPosition() {
+			t.Errorf("have: command '%s', position %s (actual: %s), but want: '%s'",
+				pgErr.Command(), pgErr.Position(), pgErr.ActualPosition(), command)
+		}
+	})
+}
@@ -172,6 +172,14 @@ func (p *Pool) Release(c *Conn) {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
 	if !p.closed {
+		// a connection broken by an I/O error can't be reused
+		if c.Status() == StatusDisconnected {
+			p.n--
+			p.log("broken connection discarded")
+			p.cond.Signal()
+			return
+		}
+
 		// reset the connection
 		c.reader = bufio.NewReader(c.tcpConn)
 		c.writer = bufio.NewWriter(c.tcpConn)
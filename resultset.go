@@ -5,10 +5,16 @@
 package pgsql
 
 import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
 	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -22,23 +28,96 @@ const (
 )
 
 type field struct {
-	name    string
-	format  fieldFormat
-	typeOID int32
+	name         string
+	format       fieldFormat
+	tableOID     int32
+	attNum       int16
+	typeOID      int32
+	typeSize     int16
+	typeModifier int32
+}
+
+// FieldDescription describes a field of a ResultSet, as reported by the
+// server.
+type FieldDescription struct {
+	Name         string
+	TableOID     int32  // OID of the table, if the field is a table column, otherwise 0
+	AttNum       int16  // Attribute number of the table column, otherwise 0
+	TypeOID      int32  // OID of the data type
+	TypeName     string // Name of the data type in upper case, empty for non built-in types
+	TypeSize     int16  // Size of the data type, negative for variable length types
+	TypeModifier int32  // Type specific modifier, -1 if there is none
+	Format       int16  // 0 for text, 1 for binary
+}
+
+func (d *FieldDescription) field() *field {
+	return &field{typeOID: d.TypeOID, typeModifier: d.TypeModifier}
+}
+
+// Length returns the maximum length of values of variable length character
+// and bit string types, like varchar(n), as declared by the type modifier.
+// For text and bytea, length is math.MaxInt64. For other types, ok is false.
+func (d *FieldDescription) Length() (length int64, ok bool) {
+	return d.field().length()
+}
+
+// PrecisionScale returns the precision and scale of numeric(p,s) fields, as
+// declared by the type modifier. For other types, ok is false.
+func (d *FieldDescription) PrecisionScale() (precision, scale int64, ok bool) {
+	return d.field().precisionScale()
+}
+
+// length returns the maximum length of values of variable length character
+// and bit string types, as declared by the type modifier. For types without
+// such a limit, like text, length is math.MaxInt64.
+func (f *field) length() (length int64, ok bool) {
+	switch f.typeOID {
+	case _BPCHAROID, _VARCHAROID:
+		if f.typeModifier == -1 {
+			return math.MaxInt64, true
+		}
+		// The modifier includes the size of the varlena header.
+		return int64(f.typeModifier - 4), true
+
+	case _BITOID, _VARBITOID:
+		if f.typeModifier == -1 {
+			return math.MaxInt64, true
+		}
+		return int64(f.typeModifier), true
+
+	case _BYTEAOID, _TEXTOID:
+		return math.MaxInt64, true
+	}
+
+	return 0, false
+}
+
+// precisionScale returns the precision and scale of numeric fields,
+// as declared by the type modifier.
+func (f *field) precisionScale() (precision, scale int64, ok bool) {
+	if f.typeOID != _NUMERICOID || f.typeModifier == -1 {
+		return 0, 0, false
+	}
+
+	mod := f.typeModifier - 4
+
+	return int64(mod >> 16 & 0xffff), int64(mod & 0xffff), true
 }
 
 // ResultSet reads the results of a query, row by row, and provides methods to
 // retrieve field values of the current row.
 //
-// Access is by 0-based field ordinal position.
+// Access is by 0-based field ordinal position, see Get, Values and Map for
+// access by field name.
 type ResultSet struct {
 	conn                  *Conn
 	stmt                  *Statement
 	hasCurrentRow         bool
 	currentResultComplete bool
 	allResultsComplete    bool
-	rowsAffected          int64
-	name2ord              map[string]int
+	commandTag            CommandTag
+	name2ord              map[string]int  // Ordinal of the first field with a name
+	duplicateNames        map[string]bool // Names shared by several fields
 	fields                []field
 	values                [][]byte
 }
@@ -56,11 +135,25 @@ func (rs *ResultSet) initializeResult() {
 		defer rs.conn.logExit(rs.conn.logEnter("*ResultSet.initializeResult"))
 	}
 
-	rs.conn.readRowDescription(rs)
+	rs.setFields(rs.conn.readRowDescription())
+}
+
+func (rs *ResultSet) setFields(fields []field) {
+	rs.fields = fields
+	rs.values = make([][]byte, len(fields))
 
 	rs.name2ord = make(map[string]int)
+	rs.duplicateNames = nil
 
 	for ord, field := range rs.fields {
+		if _, ok := rs.name2ord[field.name]; ok {
+			if rs.duplicateNames == nil {
+				rs.duplicateNames = make(map[string]bool)
+			}
+			rs.duplicateNames[field.name] = true
+			continue
+		}
+
 		rs.name2ord[field.name] = ord
 	}
 
@@ -101,6 +194,12 @@ func (rs *ResultSet) Conn() *Conn {
 	return rs.conn
 }
 
+// CommandTag returns the command tag of the most recently completed result,
+// i.e. of the current result, once FetchNext has returned false for it.
+func (rs *ResultSet) CommandTag() CommandTag {
+	return rs.commandTag
+}
+
 // Statement returns the *Statement this ResultSet is associated with.
 func (rs *ResultSet) Statement() *Statement {
 	return rs.stmt
@@ -114,6 +213,10 @@ func (rs *ResultSet) nextResult() bool {
 	rs.eatCurrentResultRows()
 
 	if !rs.allResultsComplete {
+		// The next result may not have a RowDescription, e.g. for an INSERT,
+		// so forget about the fields of the current one.
+		rs.setFields(nil)
+
 		rs.conn.readBackendMessages(rs)
 	}
 
@@ -123,8 +226,8 @@ func (rs *ResultSet) nextResult() bool {
 // NextResult moves the ResultSet to the next result, if there is one.
 //
 // In this case true is returned, otherwise false.
-// Statements support a single result only, use *Conn.Query if you need
-// this functionality.
+// Statements support a single result only, use *Conn.Query without
+// parameters if you need this functionality.
 func (rs *ResultSet) NextResult() (hasResult bool, err error) {
 	err = rs.conn.withRecover("*ResultSet.NextResult", func() {
 		hasResult = rs.nextResult()
@@ -149,7 +252,8 @@ func (rs *ResultSet) fetchNext() bool {
 
 func (rs *ResultSet) setCompletedOnPgsqlError(err error) {
 	if err != nil && !rs.hasCurrentRow {
-		if _, ok := err.(*Error); ok {
+		var pgErr *Error
+		if errors.As(err, &pgErr) {
 			// This is likely an exception raised by a user defined PostgreSQL
 			// function.
 			// FIXME: Not sure if this handling is sane.
@@ -177,10 +281,6 @@ func (rs *ResultSet) close() {
 		defer rs.conn.logExit(rs.conn.logEnter("*ResultSet.close"))
 	}
 
-	if rs.stmt != nil {
-		defer rs.conn.writeClose('P', rs.stmt.portalName)
-	}
-
 	// TODO: Instead of eating all records, try to cancel the query processing.
 	// (The required message has to be sent through another connection though.)
 	rs.eatAllResultRows()
@@ -206,7 +306,7 @@ func (rs *ResultSet) isNull(ord int) bool {
 	// Since all field value retrieval methods call this method,
 	// we only check for a valid current row here.
 	if !rs.hasCurrentRow {
-		panic("invalid row")
+		panic(ErrNoCurrentRow)
 	}
 
 	return rs.values[ord] == nil
@@ -256,8 +356,30 @@ func (rs *ResultSet) Type(ord int) (typ Type, err error) {
 	return
 }
 
+// Fields returns descriptions of the fields of the current result.
+func (rs *ResultSet) Fields() []FieldDescription {
+	descs := make([]FieldDescription, len(rs.fields))
+
+	for i, f := range rs.fields {
+		descs[i] = FieldDescription{
+			Name:         f.name,
+			TableOID:     f.tableOID,
+			AttNum:       f.attNum,
+			TypeOID:      f.typeOID,
+			TypeName:     typeName(f.typeOID),
+			TypeSize:     f.typeSize,
+			TypeModifier: f.typeModifier,
+			Format:       int16(f.format),
+		}
+	}
+
+	return descs
+}
+
 // Ordinal returns the 0-based ordinal position of the field with the
 // specified name, or -1 if the ResultSet has no field with such a name.
+//
+// If several fields have the name, the ordinal of the first one is returned.
 func (rs *ResultSet) Ordinal(name string) int {
 	if rs.conn.LogLevel >= LogVerbose {
 		defer rs.conn.logExit(rs.conn.logEnter("*ResultSet.Ordinal"))
@@ -271,6 +393,42 @@ func (rs *ResultSet) Ordinal(name string) int {
 	return ord
 }
 
+// ordinal returns the ordinal of the field with the specified name and
+// panics if there is none or the name is ambiguous.
+func (rs *ResultSet) ordinal(name string) int {
+	if rs.duplicateNames[name] {
+		panic(errors.New(fmt.Sprintf("field name '%s' is ambiguous", name)))
+	}
+
+	ord, ok := rs.name2ord[name]
+	if !ok {
+		panic(errors.New(fmt.Sprintf("no field named '%s'", name)))
+	}
+
+	return ord
+}
+
+// Get returns the value of the field with the specified name in the current
+// row of rs as T, which can be any type supported as destination by
+// *ResultSet.Scan, e.g.:
+//
+//	name, isNull, err := pgsql.Get[string](rs, "name")
+//
+// For NULL, the zero value of T is returned and isNull is true. Names shared
+// by several fields cause an error, use the accessor methods taking an
+// ordinal for those.
+func Get[T any](rs *ResultSet, name string) (value T, isNull bool, err error) {
+	err = rs.conn.withRecover("pgsql.Get", func() {
+		ord := rs.ordinal(name)
+
+		isNull = rs.isNull(ord)
+
+		rs.scanField(ord, &value)
+	})
+
+	return
+}
+
 func (rs *ResultSet) bool(ord int) (value, isNull bool) {
 	if rs.conn.LogLevel >= LogVerbose {
 		defer rs.conn.logExit(rs.conn.logEnter("*ResultSet.bool"))
@@ -528,7 +686,7 @@ func (rs *ResultSet) rat(ord int) (value *big.Rat, isNull bool) {
 	case textFormat:
 		x := big.NewRat(1, 1)
 		if _, ok := x.SetString(string(val)); !ok {
-			panic("*big.Rat.SetString failed")
+			panic(fmt.Errorf("invalid numeric value: '%s'", val))
 		}
 		value = x
 
@@ -572,6 +730,77 @@ func (rs *ResultSet) String(ord int) (value string, isNull bool, err error) {
 	return
 }
 
+// decodeBytea decodes the text representation of a bytea value, which is
+// either in hex format, like \x5c00, or in escape format, like \\\000.
+func decodeBytea(s []byte) []byte {
+	if bytes.HasPrefix(s, []byte(`\x`)) {
+		value := make([]byte, hex.DecodedLen(len(s)-2))
+		if _, err := hex.Decode(value, s[2:]); err != nil {
+			panic(fmt.Errorf("invalid bytea value: %w", err))
+		}
+
+		return value
+	}
+
+	value := make([]byte, 0, len(s))
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] != '\\':
+			value = append(value, s[i])
+
+		case i+1 < len(s) && s[i+1] == '\\':
+			value = append(value, '\\')
+			i++
+
+		case i+3 < len(s):
+			b, err := strconv.ParseUint(string(s[i+1:i+4]), 8, 8)
+			if err != nil {
+				panic(fmt.Errorf("invalid bytea value: %w", err))
+			}
+			value = append(value, byte(b))
+			i += 3
+
+		default:
+			panic(errors.New("invalid bytea value: incomplete escape sequence"))
+		}
+	}
+
+	return value
+}
+
+func (rs *ResultSet) bytes(ord int) (value []byte, isNull bool) {
+	if rs.conn.LogLevel >= LogVerbose {
+		defer rs.conn.logExit(rs.conn.logEnter("*ResultSet.bytes"))
+	}
+
+	isNull = rs.isNull(ord)
+	if isNull {
+		return
+	}
+
+	val := rs.values[ord]
+
+	if rs.fields[ord].typeOID == _BYTEAOID && rs.fields[ord].format == textFormat {
+		value = decodeBytea(val)
+	} else {
+		value = append([]byte(nil), val...)
+	}
+
+	return
+}
+
+// Bytes returns the value of the field with the specified ordinal as []byte.
+// Values of type bytea are decoded, for other types the text representation is
+// returned.
+func (rs *ResultSet) Bytes(ord int) (value []byte, isNull bool, err error) {
+	err = rs.conn.withRecover("*ResultSet.Bytes", func() {
+		value, isNull = rs.bytes(ord)
+	})
+
+	return
+}
+
 func (rs *ResultSet) time(ord int) (value time.Time, isNull bool) {
 	if rs.conn.LogLevel >= LogVerbose {
 		defer rs.conn.logExit(rs.conn.logEnter("*ResultSet.Time"))
@@ -754,6 +983,9 @@ func (rs *ResultSet) any(ord int) (value interface{}, isNull bool) {
 	case _BOOLOID:
 		value, isNull = rs.bool(ord)
 
+	case _BYTEAOID:
+		value, isNull = rs.bytes(ord)
+
 	case _BPCHAROID, _CHAROID, _VARCHAROID, _TEXTOID:
 		value, isNull = rs.string(ord)
 
@@ -779,7 +1011,7 @@ func (rs *ResultSet) any(ord int) (value interface{}, isNull bool) {
 		value, isNull = rs.rat(ord)
 
 	default:
-		panic(fmt.Sprintf("unexpected field type: field: '%s' OID: %d", rs.fields[ord].name, rs.fields[ord].typeOID))
+		value, isNull = rs.string(ord)
 	}
 
 	return
@@ -793,6 +1025,7 @@ func (rs *ResultSet) any(ord int) (value interface{}, isNull bool) {
 //
 //	Bigint		int64
 //	Boolean		bool
+//	Bytea		[]byte
 //	Char		string
 //	Date		int64
 //	Double		float64
@@ -806,6 +1039,8 @@ func (rs *ResultSet) any(ord int) (value interface{}, isNull bool) {
 //	Timestamp	time.Time
 //	TimestampTZ	time.Time
 //	Varchar		string
+//
+// Values of other types are returned as string.
 func (rs *ResultSet) Any(ord int) (value interface{}, isNull bool, err error) {
 	err = rs.conn.withRecover("*ResultSet.Any", func() {
 		value, isNull = rs.any(ord)
@@ -814,89 +1049,194 @@ func (rs *ResultSet) Any(ord int) (value interface{}, isNull bool, err error) {
 	return
 }
 
+// Values returns the values of all fields of the current row, in field order,
+// as returned by Any. NULL values are nil.
+func (rs *ResultSet) Values() (values []interface{}, err error) {
+	err = rs.conn.withRecover("*ResultSet.Values", func() {
+		values = make([]interface{}, len(rs.fields))
+
+		for ord := range rs.fields {
+			if !rs.isNull(ord) {
+				values[ord], _ = rs.any(ord)
+			}
+		}
+	})
+
+	return
+}
+
+// Map returns the values of all fields of the current row by field name, as
+// returned by Any. NULL values are nil.
+//
+// If several fields have the same name, Map returns an error, because their
+// values could not be told apart. Use Values in that case or rename the
+// columns in the query.
+func (rs *ResultSet) Map() (m map[string]interface{}, err error) {
+	err = rs.conn.withRecover("*ResultSet.Map", func() {
+		for name := range rs.duplicateNames {
+			panic(errors.New(fmt.Sprintf("field name '%s' is ambiguous", name)))
+		}
+
+		m = make(map[string]interface{}, len(rs.fields))
+
+		for ord, f := range rs.fields {
+			m[f.name] = nil
+			if !rs.isNull(ord) {
+				m[f.name], _ = rs.any(ord)
+			}
+		}
+	})
+
+	return
+}
+
 func (rs *ResultSet) scan(args ...interface{}) {
 	if rs.conn.LogLevel >= LogVerbose {
 		defer rs.conn.logExit(rs.conn.logEnter("*ResultSet.Scan"))
 	}
 
 	if len(args) != len(rs.fields) {
-		panic("wrong argument count")
+		panic(&ArgumentCountError{Have: len(args), Want: len(rs.fields)})
 	}
 
 	for i, arg := range args {
-		switch a := arg.(type) {
-		case *bool:
-			*a, _ = rs.bool(i)
+		rs.scanField(i, arg)
+	}
 
-		case *float32:
-			*a, _ = rs.float32(i)
+	return
+}
 
-		case *float64:
-			*a, _ = rs.float64(i)
+// driverValue returns the value of the field with the specified ordinal as
+// one of the types allowed for a driver.Value, which sql.Scanner
+// implementations expect.
+func (rs *ResultSet) driverValue(ord int) driver.Value {
+	value, isNull := rs.any(ord)
+	if isNull {
+		return nil
+	}
 
-		case *int:
-			*a, _ = rs.int(i)
+	switch v := value.(type) {
+	case float32:
+		return float64(v)
 
-		case *int16:
-			*a, _ = rs.int16(i)
+	case int:
+		return int64(v)
 
-		case *int32:
-			*a, _ = rs.int32(i)
+	case int16:
+		return int64(v)
 
-		case *int64:
-			switch rs.fields[i].typeOID {
-			case _DATEOID, _TIMEOID, _TIMETZOID, _TIMESTAMPOID, _TIMESTAMPTZOID:
-				*a, _ = rs.timeSeconds(i)
+	case *big.Rat:
+		s, _ := rs.string(ord)
+		return s
+	}
 
-			default:
-				*a, _ = rs.int64(i)
-			}
+	return value
+}
 
-		case *interface{}:
-			*a, _ = rs.any(i)
+func (rs *ResultSet) scanField(i int, arg interface{}) {
+	if scanner, ok := arg.(sql.Scanner); ok {
+		if !rs.hasCurrentRow {
+			panic(ErrNoCurrentRow)
+		}
 
-		case **big.Rat:
-			var r *big.Rat
-			r, _ = rs.rat(i)
-			*a = r
+		panicIfErr(scanner.Scan(rs.driverValue(i)))
+		return
+	}
 
-		case *string:
-			*a, _ = rs.string(i)
+	switch a := arg.(type) {
+	case *bool:
+		*a, _ = rs.bool(i)
 
-		case *time.Time:
-			var t time.Time
-			t, _ = rs.time(i)
-			*a = t
+	case *float32:
+		*a, _ = rs.float32(i)
 
-		case *uint:
-			*a, _ = rs.uint(i)
+	case *float64:
+		*a, _ = rs.float64(i)
 
-		case *uint16:
-			*a, _ = rs.uint16(i)
+	case *int:
+		*a, _ = rs.int(i)
 
-		case *uint32:
-			*a, _ = rs.uint32(i)
+	case *int16:
+		*a, _ = rs.int16(i)
 
-		case *uint64:
-			switch rs.fields[i].typeOID {
-			case _DATEOID, _TIMEOID, _TIMETZOID, _TIMESTAMPOID, _TIMESTAMPTZOID:
-				var seconds int64
-				seconds, _ = rs.timeSeconds(i)
-				*a = uint64(seconds)
+	case *int32:
+		*a, _ = rs.int32(i)
 
-			default:
-				*a, _ = rs.uint64(i)
-			}
+	case *int64:
+		switch rs.fields[i].typeOID {
+		case _DATEOID, _TIMEOID, _TIMETZOID, _TIMESTAMPOID, _TIMESTAMPTZOID:
+			*a, _ = rs.timeSeconds(i)
+
+		default:
+			*a, _ = rs.int64(i)
 		}
-	}
 
-	return
+	case *interface{}:
+		*a, _ = rs.any(i)
+
+	case **big.Rat:
+		var r *big.Rat
+		r, _ = rs.rat(i)
+		*a = r
+
+	case *string:
+		*a, _ = rs.string(i)
+
+	case *time.Time:
+		var t time.Time
+		t, _ = rs.time(i)
+		*a = t
+
+	case *uint:
+		*a, _ = rs.uint(i)
+
+	case *uint16:
+		*a, _ = rs.uint16(i)
+
+	case *uint32:
+		*a, _ = rs.uint32(i)
+
+	case *uint64:
+		switch rs.fields[i].typeOID {
+		case _DATEOID, _TIMEOID, _TIMETZOID, _TIMESTAMPOID, _TIMESTAMPTZOID:
+			var seconds int64
+			seconds, _ = rs.timeSeconds(i)
+			*a = uint64(seconds)
+
+		default:
+			*a, _ = rs.uint64(i)
+		}
+
+	case *[]byte:
+		*a, _ = rs.bytes(i)
+
+	default:
+		ptr := reflect.ValueOf(arg)
+		if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Ptr {
+			panic(errors.New(fmt.Sprintf("unsupported destination type %T for field %d", arg, i)))
+		}
+
+		// Pointer to pointer, which is set to nil for NULL.
+		if rs.isNull(i) {
+			ptr.Elem().Set(reflect.Zero(ptr.Elem().Type()))
+			return
+		}
+
+		value := reflect.New(ptr.Elem().Type().Elem())
+		rs.scanField(i, value.Interface())
+		ptr.Elem().Set(value)
+	}
 }
 
 // Scan scans the fields of the current row in the ResultSet, trying
 // to store field values into the specified arguments.
 //
-// The arguments must be of pointer types.
+// The arguments must be of pointer types. Besides pointers to the types
+// supported by the field accessor methods, sql.Scanner implementations like
+// *sql.NullString and pointers to pointers, like **string, are supported.
+// NULL values leave the zero value in plain destinations, the latter two
+// allow to tell them apart: a pointer to pointer is set to nil for NULL.
+// Unsupported destination types cause an error.
 func (rs *ResultSet) Scan(args ...interface{}) (err error) {
 	err = rs.conn.withRecover("*ResultSet.Scan", func() {
 		rs.scan(args...)
@@ -0,0 +1,90 @@
+// Copyright 2026 The go-pgsql Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pgsql
+
+import (
+	"errors"
+	"iter"
+)
+
+// ErrNoRows is returned by CollectOneRow, if the ResultSet has no rows.
+var ErrNoRows = errors.New("pgsql: no rows in result set")
+
+// Rows returns an iterator over the rows of rs, which yields the value scan
+// returns for each row:
+//
+//	rs, err := conn.Query("SELECT name FROM table1;")
+//	if err != nil {
+//		return err
+//	}
+//
+//	for name, err := range pgsql.Rows(rs, func(rs *pgsql.ResultSet) (name string, err error) {
+//		err = rs.Scan(&name)
+//		return
+//	}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(name)
+//	}
+//
+// The iteration stops after the first error, which is either returned by
+// scan or by fetching the next row. rs is closed when the iteration ends,
+// including an early break, so the iterator can only be used once.
+func Rows[T any](rs *ResultSet, scan func(rs *ResultSet) (T, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		for {
+			hasRow, err := rs.FetchNext()
+			if err != nil {
+				rs.Close()
+				yield(zero, err)
+				return
+			}
+
+			if !hasRow {
+				break
+			}
+
+			value, err := scan(rs)
+			if !yield(value, err) || err != nil {
+				rs.Close()
+				return
+			}
+		}
+
+		if err := rs.Close(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// CollectRows returns the values scan returns for all rows of rs, which is
+// closed afterwards.
+func CollectRows[T any](rs *ResultSet, scan func(rs *ResultSet) (T, error)) ([]T, error) {
+	var values []T
+
+	for value, err := range Rows(rs, scan) {
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// CollectOneRow returns the value scan returns for the first row of rs, which
+// is closed afterwards. If rs has no rows, ErrNoRows is returned.
+func CollectOneRow[T any](rs *ResultSet, scan func(rs *ResultSet) (T, error)) (T, error) {
+	for value, err := range Rows(rs, scan) {
+		return value, err
+	}
+
+	var zero T
+	return zero, ErrNoRows
+}
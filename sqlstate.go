@@ -0,0 +1,422 @@
+// Copyright 2026 The go-pgsql Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pgsql
+
+import (
+	"errors"
+)
+
+// SQLState is a five character SQLSTATE error code, as returned by
+// *Error.Code.
+//
+// SQLState implements error, so codes can be used as sentinels with
+// errors.Is, which is true for an *Error with that code. The class codes
+// ending in 000, like IntegrityConstraintViolation, match all codes of
+// their class:
+//
+//	if errors.Is(err, pgsql.UniqueViolation) {
+//		// Handle duplicate key
+//	}
+type SQLState string
+
+func (s SQLState) Error() string {
+	return "pgsql: SQLSTATE " + string(s)
+}
+
+// Class returns the class of the code, i.e. its first two characters.
+func (s SQLState) Class() string {
+	if len(s) < 2 {
+		return string(s)
+	}
+
+	return string(s[:2])
+}
+
+// isClass returns if s is the code of a whole class, like 23000.
+func (s SQLState) isClass() bool {
+	return len(s) == 5 && s[2:] == "000"
+}
+
+// IsUniqueViolation returns if err is an *Error with code UniqueViolation.
+func IsUniqueViolation(err error) bool {
+	return errors.Is(err, UniqueViolation)
+}
+
+// IsForeignKeyViolation returns if err is an *Error with code
+// ForeignKeyViolation.
+func IsForeignKeyViolation(err error) bool {
+	return errors.Is(err, ForeignKeyViolation)
+}
+
+// IsNotNullViolation returns if err is an *Error with code NotNullViolation.
+func IsNotNullViolation(err error) bool {
+	return errors.Is(err, NotNullViolation)
+}
+
+// IsCheckViolation returns if err is an *Error with code CheckViolation.
+func IsCheckViolation(err error) bool {
+	return errors.Is(err, CheckViolation)
+}
+
+// IsSerializationFailure returns if err is an *Error with code
+// SerializationFailure.
+func IsSerializationFailure(err error) bool {
+	return errors.Is(err, SerializationFailure)
+}
+
+// IsDeadlockDetected returns if err is an *Error with code DeadlockDetected.
+func IsDeadlockDetected(err error) bool {
+	return errors.Is(err, DeadlockDetected)
+}
+
+// SQLState codes as listed in Appendix A "PostgreSQL Error Codes" of the
+// PostgreSQL documentation.
+const (
+	// Class 00 - Successful Completion
+	SuccessfulCompletion SQLState = "00000"
+
+	// Class 01 - Warning
+	Warning                          SQLState = "01000"
+	DynamicResultSetsReturned        SQLState = "0100C"
+	ImplicitZeroBitPadding           SQLState = "01008"
+	NullValueEliminatedInSetFunction SQLState = "01003"
+	PrivilegeNotGranted              SQLState = "01007"
+	PrivilegeNotRevoked              SQLState = "01006"
+	StringDataRightTruncationWarning SQLState = "01004"
+	DeprecatedFeature                SQLState = "01P01"
+
+	// Class 02 - No Data (this is also a warning class per the SQL standard)
+	NoData                                SQLState = "02000"
+	NoAdditionalDynamicResultSetsReturned SQLState = "02001"
+
+	// Class 03 - SQL Statement Not Yet Complete
+	SQLStatementNotYetComplete SQLState = "03000"
+
+	// Class 08 - Connection Exception
+	ConnectionException                           SQLState = "08000"
+	ConnectionDoesNotExist                        SQLState = "08003"
+	ConnectionFailure                             SQLState = "08006"
+	SQLClientUnableToEstablishSQLConnection       SQLState = "08001"
+	SQLServerRejectedEstablishmentOfSQLConnection SQLState = "08004"
+	TransactionResolutionUnknown                  SQLState = "08007"
+	ProtocolViolation                             SQLState = "08P01"
+
+	// Class 09 - Triggered Action Exception
+	TriggeredActionException SQLState = "09000"
+
+	// Class 0A - Feature Not Supported
+	FeatureNotSupported SQLState = "0A000"
+
+	// Class 0B - Invalid Transaction Initiation
+	InvalidTransactionInitiation SQLState = "0B000"
+
+	// Class 0F - Locator Exception
+	LocatorException            SQLState = "0F000"
+	InvalidLocatorSpecification SQLState = "0F001"
+
+	// Class 0L - Invalid Grantor
+	InvalidGrantor        SQLState = "0L000"
+	InvalidGrantOperation SQLState = "0LP01"
+
+	// Class 0P - Invalid Role Specification
+	InvalidRoleSpecification SQLState = "0P000"
+
+	// Class 0Z - Diagnostics Exception
+	DiagnosticsException                           SQLState = "0Z000"
+	StackedDiagnosticsAccessedWithoutActiveHandler SQLState = "0Z002"
+
+	// Class 20 - Case Not Found
+	CaseNotFound SQLState = "20000"
+
+	// Class 21 - Cardinality Violation
+	CardinalityViolation SQLState = "21000"
+
+	// Class 22 - Data Exception
+	DataException                             SQLState = "22000"
+	ArraySubscriptError                       SQLState = "2202E"
+	CharacterNotInRepertoire                  SQLState = "22021"
+	DatetimeFieldOverflow                     SQLState = "22008"
+	DivisionByZero                            SQLState = "22012"
+	ErrorInAssignment                         SQLState = "22005"
+	EscapeCharacterConflict                   SQLState = "2200B"
+	IndicatorOverflow                         SQLState = "22022"
+	IntervalFieldOverflow                     SQLState = "22015"
+	InvalidArgumentForLogarithm               SQLState = "2201E"
+	InvalidArgumentForNtileFunction           SQLState = "22014"
+	InvalidArgumentForNthValueFunction        SQLState = "22016"
+	InvalidArgumentForPowerFunction           SQLState = "2201F"
+	InvalidArgumentForWidthBucketFunction     SQLState = "2201G"
+	InvalidCharacterValueForCast              SQLState = "22018"
+	InvalidDatetimeFormat                     SQLState = "22007"
+	InvalidEscapeCharacter                    SQLState = "22019"
+	InvalidEscapeOctet                        SQLState = "2200D"
+	InvalidEscapeSequence                     SQLState = "22025"
+	NonstandardUseOfEscapeCharacter           SQLState = "22P06"
+	InvalidIndicatorParameterValue            SQLState = "22010"
+	InvalidParameterValue                     SQLState = "22023"
+	InvalidPrecedingOrFollowingSize           SQLState = "22013"
+	InvalidRegularExpression                  SQLState = "2201B"
+	InvalidRowCountInLimitClause              SQLState = "2201W"
+	InvalidRowCountInResultOffsetClause       SQLState = "2201X"
+	InvalidTablesampleArgument                SQLState = "2202H"
+	InvalidTablesampleRepeat                  SQLState = "2202G"
+	InvalidTimeZoneDisplacementValue          SQLState = "22009"
+	InvalidUseOfEscapeCharacter               SQLState = "2200C"
+	MostSpecificTypeMismatch                  SQLState = "2200G"
+	NullValueNotAllowedDataException          SQLState = "22004"
+	NullValueNoIndicatorParameter             SQLState = "22002"
+	NumericValueOutOfRange                    SQLState = "22003"
+	SequenceGeneratorLimitExceeded            SQLState = "2200H"
+	StringDataLengthMismatch                  SQLState = "22026"
+	StringDataRightTruncationDataException    SQLState = "22001"
+	SubstringError                            SQLState = "22011"
+	TrimError                                 SQLState = "22027"
+	UnterminatedCString                       SQLState = "22024"
+	ZeroLengthCharacterString                 SQLState = "2200F"
+	FloatingPointException                    SQLState = "22P01"
+	InvalidTextRepresentation                 SQLState = "22P02"
+	InvalidBinaryRepresentation               SQLState = "22P03"
+	BadCopyFileFormat                         SQLState = "22P04"
+	UntranslatableCharacter                   SQLState = "22P05"
+	NotAnXMLDocument                          SQLState = "2200L"
+	InvalidXMLDocument                        SQLState = "2200M"
+	InvalidXMLContent                         SQLState = "2200N"
+	InvalidXMLComment                         SQLState = "2200S"
+	InvalidXMLProcessingInstruction           SQLState = "2200T"
+	DuplicateJSONObjectKeyValue               SQLState = "22030"
+	InvalidArgumentForSQLJSONDatetimeFunction SQLState = "22031"
+	InvalidJSONText                           SQLState = "22032"
+	InvalidSQLJSONSubscript                   SQLState = "22033"
+	MoreThanOneSQLJSONItem                    SQLState = "22034"
+	NoSQLJSONItem                             SQLState = "22035"
+	NonNumericSQLJSONItem                     SQLState = "22036"
+	NonUniqueKeysInAJSONObject                SQLState = "22037"
+	SingletonSQLJSONItemRequired              SQLState = "22038"
+	SQLJSONArrayNotFound                      SQLState = "22039"
+	SQLJSONMemberNotFound                     SQLState = "2203A"
+	SQLJSONNumberNotFound                     SQLState = "2203B"
+	SQLJSONObjectNotFound                     SQLState = "2203C"
+	TooManyJSONArrayElements                  SQLState = "2203D"
+	TooManyJSONObjectMembers                  SQLState = "2203E"
+	SQLJSONScalarRequired                     SQLState = "2203F"
+	SQLJSONItemCannotBeCastToTargetType       SQLState = "2203G"
+
+	// Class 23 - Integrity Constraint Violation
+	IntegrityConstraintViolation SQLState = "23000"
+	RestrictViolation            SQLState = "23001"
+	NotNullViolation             SQLState = "23502"
+	ForeignKeyViolation          SQLState = "23503"
+	UniqueViolation              SQLState = "23505"
+	CheckViolation               SQLState = "23514"
+	ExclusionViolation           SQLState = "23P01"
+
+	// Class 24 - Invalid Cursor State
+	InvalidCursorState SQLState = "24000"
+
+	// Class 25 - Invalid Transaction State
+	InvalidTransactionState                         SQLState = "25000"
+	ActiveSQLTransaction                            SQLState = "25001"
+	BranchTransactionAlreadyActive                  SQLState = "25002"
+	HeldCursorRequiresSameIsolationLevel            SQLState = "25008"
+	InappropriateAccessModeForBranchTransaction     SQLState = "25003"
+	InappropriateIsolationLevelForBranchTransaction SQLState = "25004"
+	NoActiveSQLTransactionForBranchTransaction      SQLState = "25005"
+	ReadOnlySQLTransaction                          SQLState = "25006"
+	SchemaAndDataStatementMixingNotSupported        SQLState = "25007"
+	NoActiveSQLTransaction                          SQLState = "25P01"
+	InFailedSQLTransaction                          SQLState = "25P02"
+	IdleInTransactionSessionTimeout                 SQLState = "25P03"
+
+	// Class 26 - Invalid SQL Statement Name
+	InvalidSQLStatementName SQLState = "26000"
+
+	// Class 27 - Triggered Data Change Violation
+	TriggeredDataChangeViolation SQLState = "27000"
+
+	// Class 28 - Invalid Authorization Specification
+	InvalidAuthorizationSpecification SQLState = "28000"
+	InvalidPassword                   SQLState = "28P01"
+
+	// Class 2B - Dependent Privilege Descriptors Still Exist
+	DependentPrivilegeDescriptorsStillExist SQLState = "2B000"
+	DependentObjectsStillExist              SQLState = "2BP01"
+
+	// Class 2D - Invalid Transaction Termination
+	InvalidTransactionTermination SQLState = "2D000"
+
+	// Class 2F - SQL Routine Exception
+	SQLRoutineException                                SQLState = "2F000"
+	FunctionExecutedNoReturnStatement                  SQLState = "2F005"
+	ModifyingSQLDataNotPermittedSQLRoutineException    SQLState = "2F002"
+	ProhibitedSQLStatementAttemptedSQLRoutineException SQLState = "2F003"
+	ReadingSQLDataNotPermittedSQLRoutineException      SQLState = "2F004"
+
+	// Class 34 - Invalid Cursor Name
+	InvalidCursorName SQLState = "34000"
+
+	// Class 38 - External Routine Exception
+	ExternalRoutineException                                SQLState = "38000"
+	ContainingSQLNotPermitted                               SQLState = "38001"
+	ModifyingSQLDataNotPermittedExternalRoutineException    SQLState = "38002"
+	ProhibitedSQLStatementAttemptedExternalRoutineException SQLState = "38003"
+	ReadingSQLDataNotPermittedExternalRoutineException      SQLState = "38004"
+
+	// Class 39 - External Routine Invocation Exception
+	ExternalRoutineInvocationException                    SQLState = "39000"
+	InvalidSQLstateReturned                               SQLState = "39001"
+	NullValueNotAllowedExternalRoutineInvocationException SQLState = "39004"
+	TriggerProtocolViolated                               SQLState = "39P01"
+	SRFProtocolViolated                                   SQLState = "39P02"
+	EventTriggerProtocolViolated                          SQLState = "39P03"
+
+	// Class 3B - Savepoint Exception
+	SavepointException            SQLState = "3B000"
+	InvalidSavepointSpecification SQLState = "3B001"
+
+	// Class 3D - Invalid Catalog Name
+	InvalidCatalogName SQLState = "3D000"
+
+	// Class 3F - Invalid Schema Name
+	InvalidSchemaName SQLState = "3F000"
+
+	// Class 40 - Transaction Rollback
+	TransactionRollback                     SQLState = "40000"
+	TransactionIntegrityConstraintViolation SQLState = "40002"
+	SerializationFailure                    SQLState = "40001"
+	StatementCompletionUnknown              SQLState = "40003"
+	DeadlockDetected                        SQLState = "40P01"
+
+	// Class 42 - Syntax Error or Access Rule Violation
+	SyntaxErrorOrAccessRuleViolation   SQLState = "42000"
+	SyntaxError                        SQLState = "42601"
+	InsufficientPrivilege              SQLState = "42501"
+	CannotCoerce                       SQLState = "42846"
+	GroupingError                      SQLState = "42803"
+	WindowingError                     SQLState = "42P20"
+	InvalidRecursion                   SQLState = "42P19"
+	InvalidForeignKey                  SQLState = "42830"
+	InvalidName                        SQLState = "42602"
+	NameTooLong                        SQLState = "42622"
+	ReservedName                       SQLState = "42939"
+	DatatypeMismatch                   SQLState = "42804"
+	IndeterminateDatatype              SQLState = "42P18"
+	CollationMismatch                  SQLState = "42P21"
+	IndeterminateCollation             SQLState = "42P22"
+	WrongObjectType                    SQLState = "42809"
+	GeneratedAlways                    SQLState = "428C9"
+	UndefinedColumn                    SQLState = "42703"
+	UndefinedFunction                  SQLState = "42883"
+	UndefinedTable                     SQLState = "42P01"
+	UndefinedParameter                 SQLState = "42P02"
+	UndefinedObject                    SQLState = "42704"
+	DuplicateColumn                    SQLState = "42701"
+	DuplicateCursor                    SQLState = "42P03"
+	DuplicateDatabase                  SQLState = "42P04"
+	DuplicateFunction                  SQLState = "42723"
+	DuplicatePreparedStatement         SQLState = "42P05"
+	DuplicateSchema                    SQLState = "42P06"
+	DuplicateTable                     SQLState = "42P07"
+	DuplicateAlias                     SQLState = "42712"
+	DuplicateObject                    SQLState = "42710"
+	AmbiguousColumn                    SQLState = "42702"
+	AmbiguousFunction                  SQLState = "42725"
+	AmbiguousParameter                 SQLState = "42P08"
+	AmbiguousAlias                     SQLState = "42P09"
+	InvalidColumnReference             SQLState = "42P10"
+	InvalidColumnDefinition            SQLState = "42611"
+	InvalidCursorDefinition            SQLState = "42P11"
+	InvalidDatabaseDefinition          SQLState = "42P12"
+	InvalidFunctionDefinition          SQLState = "42P13"
+	InvalidPreparedStatementDefinition SQLState = "42P14"
+	InvalidSchemaDefinition            SQLState = "42P15"
+	InvalidTableDefinition             SQLState = "42P16"
+	InvalidObjectDefinition            SQLState = "42P17"
+
+	// Class 44 - WITH CHECK OPTION Violation
+	WithCheckOptionViolation SQLState = "44000"
+
+	// Class 53 - Insufficient Resources
+	InsufficientResources      SQLState = "53000"
+	DiskFull                   SQLState = "53100"
+	OutOfMemory                SQLState = "53200"
+	TooManyConnections         SQLState = "53300"
+	ConfigurationLimitExceeded SQLState = "53400"
+
+	// Class 54 - Program Limit Exceeded
+	ProgramLimitExceeded SQLState = "54000"
+	StatementTooComplex  SQLState = "54001"
+	TooManyColumns       SQLState = "54011"
+	TooManyArguments     SQLState = "54023"
+
+	// Class 55 - Object Not In Prerequisite State
+	ObjectNotInPrerequisiteState SQLState = "55000"
+	ObjectInUse                  SQLState = "55006"
+	CantChangeRuntimeParam       SQLState = "55P02"
+	LockNotAvailable             SQLState = "55P03"
+	UnsafeNewEnumValueUsage      SQLState = "55P04"
+
+	// Class 57 - Operator Intervention
+	OperatorIntervention SQLState = "57000"
+	QueryCanceled        SQLState = "57014"
+	AdminShutdown        SQLState = "57P01"
+	CrashShutdown        SQLState = "57P02"
+	CannotConnectNow     SQLState = "57P03"
+	DatabaseDropped      SQLState = "57P04"
+	IdleSessionTimeout   SQLState = "57P05"
+
+	// Class 58 - System Error (errors external to PostgreSQL itself)
+	SystemError   SQLState = "58000"
+	IOError       SQLState = "58030"
+	UndefinedFile SQLState = "58P01"
+	DuplicateFile SQLState = "58P02"
+
+	// Class 72 - Snapshot Failure
+	SnapshotTooOld SQLState = "72000"
+
+	// Class F0 - Configuration File Error
+	ConfigFileError SQLState = "F0000"
+	LockFileExists  SQLState = "F0001"
+
+	// Class HV - Foreign Data Wrapper Error (SQL/MED)
+	FDWError                             SQLState = "HV000"
+	FDWColumnNameNotFound                SQLState = "HV005"
+	FDWDynamicParameterValueNeeded       SQLState = "HV002"
+	FDWFunctionSequenceError             SQLState = "HV010"
+	FDWInconsistentDescriptorInformation SQLState = "HV021"
+	FDWInvalidAttributeValue             SQLState = "HV024"
+	FDWInvalidColumnName                 SQLState = "HV007"
+	FDWInvalidColumnNumber               SQLState = "HV008"
+	FDWInvalidDataType                   SQLState = "HV004"
+	FDWInvalidDataTypeDescriptors        SQLState = "HV006"
+	FDWInvalidDescriptorFieldIdentifier  SQLState = "HV091"
+	FDWInvalidHandle                     SQLState = "HV00B"
+	FDWInvalidOptionIndex                SQLState = "HV00C"
+	FDWInvalidOptionName                 SQLState = "HV00D"
+	FDWInvalidStringLengthOrBufferLength SQLState = "HV090"
+	FDWInvalidStringFormat               SQLState = "HV00A"
+	FDWInvalidUseOfNullPointer           SQLState = "HV009"
+	FDWTooManyHandles                    SQLState = "HV014"
+	FDWOutOfMemory                       SQLState = "HV001"
+	FDWNoSchemas                         SQLState = "HV00P"
+	FDWOptionNameNotFound                SQLState = "HV00J"
+	FDWReplyHandle                       SQLState = "HV00K"
+	FDWSchemaNotFound                    SQLState = "HV00Q"
+	FDWTableNotFound                     SQLState = "HV00R"
+	FDWUnableToCreateExecution           SQLState = "HV00L"
+	FDWUnableToCreateReply               SQLState = "HV00M"
+	FDWUnableToEstablishConnection       SQLState = "HV00N"
+
+	// Class P0 - PL/pgSQL Error
+	PLpgSQLError   SQLState = "P0000"
+	RaiseException SQLState = "P0001"
+	NoDataFound    SQLState = "P0002"
+	TooManyRows    SQLState = "P0003"
+	AssertFailure  SQLState = "P0004"
+
+	// Class XX - Internal Error
+	InternalError  SQLState = "XX000"
+	DataCorrupted  SQLState = "XX001"
+	IndexCorrupted SQLState = "XX002"
+)
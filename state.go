@@ -4,20 +4,22 @@
 
 package pgsql
 
-const invalidOpForStateMsg = "invalid operation for this state"
+import (
+	"fmt"
+)
 
 // state is the interface that all states must implement.
 type state interface {
 	// code returns the ConnStatus that matches the state.
 	code() ConnStatus
 
-	// execute sends Bind and Execute packets to the server.
+	// execute sends Bind, Execute, Close and Sync packets to the server.
 	execute(stmt *Statement, rs *ResultSet)
 
 	// flush sends a Flush packet to the server.
 	flush(conn *Conn)
 
-	// prepare sends a Parse packet to the server.
+	// prepare sends Parse, Describe and Sync packets to the server.
 	prepare(stmt *Statement)
 
 	// query sends a Query packet to the server.
@@ -29,19 +31,19 @@ type state interface {
 type abstractState struct{}
 
 func (abstractState) execute(stmt *Statement, rs *ResultSet) {
-	panic(invalidOpForStateMsg)
+	panic(ErrInvalidState)
 }
 
 func (abstractState) flush(conn *Conn) {
-	panic(invalidOpForStateMsg)
+	panic(ErrInvalidState)
 }
 
 func (abstractState) prepare(stmt *Statement) {
-	panic(invalidOpForStateMsg)
+	panic(ErrInvalidState)
 }
 
 func (abstractState) query(conn *Conn, rs *ResultSet, sql string) {
-	panic(invalidOpForStateMsg)
+	panic(ErrInvalidState)
 }
 
 // copyState is the state that is active when the connection is used
@@ -63,6 +65,22 @@ func (disconnectedState) code() ConnStatus {
 	return StatusDisconnected
 }
 
+func (disconnectedState) execute(stmt *Statement, rs *ResultSet) {
+	panic(ErrConnClosed)
+}
+
+func (disconnectedState) flush(conn *Conn) {
+	panic(ErrConnClosed)
+}
+
+func (disconnectedState) prepare(stmt *Statement) {
+	panic(ErrConnClosed)
+}
+
+func (disconnectedState) query(conn *Conn, rs *ResultSet, sql string) {
+	panic(ErrConnClosed)
+}
+
 // processingQueryState is the state that is active when
 // the results of a query are being processed.
 type processingQueryState struct {
@@ -90,33 +108,23 @@ func (readyState) execute(stmt *Statement, rs *ResultSet) {
 		defer conn.logExit(conn.logEnter("readyState.execute"))
 	}
 
-	succeeded := false
-	conn.onErrorDontRequireReadyForQuery = true
-	defer func() {
-		conn.onErrorDontRequireReadyForQuery = false
-
-		if !succeeded {
-			conn.writeSync()
-
-			conn.readBackendMessages(nil)
-		}
-	}()
+	conn.activeCommand, conn.activeStmt = stmt.command, stmt
 
+	// All packets are sent in one go, the portal gets closed as soon as it
+	// has been executed. The RowDescription was cached by prepare, so we
+	// don't need to describe the portal.
 	conn.writeBind(stmt)
+	conn.writeExecute(stmt)
+	conn.writeClose('P', stmt.portalName)
+	conn.writeSync()
 
+	// Wait for BindComplete. In case of an error, the ReadyForQuery
+	// following it will be read before panicking.
 	conn.readBackendMessages(rs)
 
-	conn.writeDescribe(stmt)
-
-	conn.readBackendMessages(rs)
-
-	conn.writeExecute(stmt)
-
-	conn.writeSync()
+	rs.setFields(stmt.fields)
 
 	conn.state = processingQueryState{}
-
-	succeeded = true
 }
 
 func (readyState) prepare(stmt *Statement) {
@@ -126,12 +134,32 @@ func (readyState) prepare(stmt *Statement) {
 		defer conn.logExit(conn.logEnter("readyState.prepare"))
 	}
 
+	conn.activeCommand, conn.activeStmt = stmt.command, stmt
+
 	conn.writeParse(stmt)
+	conn.writeDescribe('S', stmt.name)
+	conn.writeSync()
 
-	conn.onErrorDontRequireReadyForQuery = true
-	defer func() { conn.onErrorDontRequireReadyForQuery = false }()
+	// We use a ResultSet to collect the ParameterDescription
+	// and the RowDescription, if any.
+	rs := newResultSet(conn)
+	rs.stmt = stmt
+	for !rs.allResultsComplete {
+		conn.readBackendMessages(rs)
+	}
+
+	stmt.fields = rs.fields
+
+	stmt.inferParamTypes()
 
-	conn.readBackendMessages(nil)
+	// Create parameters for placeholders the caller did not declare.
+	for i := len(stmt.params); i < len(stmt.paramTypes); i++ {
+		param := NewParameter(fmt.Sprintf("$%d", i+1), stmt.paramTypes[i])
+		param.stmt = stmt
+
+		stmt.params = append(stmt.params, param)
+		stmt.name2param[param.name] = param
+	}
 }
 
 func (readyState) query(conn *Conn, rs *ResultSet, command string) {
@@ -139,6 +167,8 @@ func (readyState) query(conn *Conn, rs *ResultSet, command string) {
 		defer conn.logExit(conn.logEnter("readyState.query"))
 	}
 
+	conn.activeCommand, conn.activeStmt = command, nil
+
 	conn.writeQuery(command)
 
 	conn.readBackendMessages(rs)
@@ -6,12 +6,11 @@ package pgsql
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
-	"regexp"
+	"strings"
 )
 
-var quoteRegExp = regexp.MustCompile("['][^']*[']")
-
 // Statement is a means to efficiently execute a parameterized SQL command multiple times.
 //
 // Call *Conn.Prepare to create a new prepared Statement.
@@ -21,72 +20,114 @@ type Statement struct {
 	portalName    string
 	command       string
 	actualCommand string
+	rewrites      []paramRewrite // Parameter references replaced in command
 	isClosed      bool
 	params        []*Parameter
 	name2param    map[string]*Parameter
+	paramTypes    []Type
+	fields        []field
+	generation    uint64 // Session generation the statement was prepared in
 }
 
-func replaceParameterNameInSubstring(s, old, new string, buf *bytes.Buffer, paramRegExp *regexp.Regexp) {
-	matchIndexPairs := paramRegExp.FindAllStringIndex(s, -1)
-	prevMatchEnd := 1
-
-	for _, pair := range matchIndexPairs {
-		matchStart := pair[0]
-		matchEnd := pair[1]
+// paramRefName returns the name used to refer to p in a command, i.e. its
+// name without ':' or '@' prefix.
+func paramRefName(p *Parameter) string {
+	if strings.HasPrefix(p.name, ":") || strings.HasPrefix(p.name, "@") {
+		return p.name[1:]
+	}
 
-		buf.WriteString(s[prevMatchEnd-1 : matchStart+1])
-		buf.WriteString(new)
+	return p.name
+}
 
-		prevMatchEnd = matchEnd
-	}
+// isPositional returns if p is referred to by a $n placeholder in the command,
+// instead of by name.
+func isPositional(p *Parameter) bool {
+	return strings.HasPrefix(p.name, "$")
+}
 
-	if prevMatchEnd > 1 {
-		buf.WriteString(s[prevMatchEnd-1:])
-		return
-	}
+// adjustCommand replaces references to named parameters in command with the
+// $n placeholders PostgreSQL expects, followed by a type cast for parameters
+// of custom types.
+//
+// A parameter may be referred to multiple times. It is an error to refer to
+// an unknown parameter or not to refer to a named parameter at all.
+func adjustCommand(command string, params []*Parameter, backslashEscapes bool) string {
+	actualCommand, _ := rewriteCommand(command, params, backslashEscapes)
 
-	buf.WriteString(s)
+	return actualCommand
 }
 
-func replaceParameterName(command, old, new string) string {
-	paramRegExp := regexp.MustCompile("[\\- |\n\r\t,)(;=+/<>][:|@]" + old[1:] + "([\\- |\n\r\t,)(;=+/<>]|$)")
+// rewriteCommand is like adjustCommand, but also returns where the parameter
+// references have been replaced, see originalOffset.
+func rewriteCommand(command string, params []*Parameter, backslashEscapes bool) (actualCommand string, rewrites []paramRewrite) {
+	name2index := make(map[string]int)
+	for i, p := range params {
+		if !isPositional(p) {
+			name2index[paramRefName(p)] = i
+		}
+	}
+
+	used := make([]bool, len(params))
 
 	buf := bytes.NewBuffer(nil)
+	prevEnd := 0
 
-	quoteIndexPairs := quoteRegExp.FindAllStringIndex(command, -1)
-	prevQuoteEnd := 0
+	for _, ref := range findParamRefs(command, backslashEscapes) {
+		i, ok := name2index[ref.name]
+		if !ok {
+			panic(fmt.Errorf("unknown parameter '%s' at position %d",
+				command[ref.start:ref.end], ref.start+1))
+		}
+		used[i] = true
 
-	for _, pair := range quoteIndexPairs {
-		quoteStart := pair[0]
-		quoteEnd := pair[1]
+		buf.WriteString(command[prevEnd:ref.start])
+		actualStart := buf.Len()
+		buf.WriteString(fmt.Sprintf("$%d", i+1))
+		if p := params[i]; p.customTypeName != "" {
+			buf.WriteString("::" + p.customTypeName)
+		}
 
-		replaceParameterNameInSubstring(command[prevQuoteEnd:quoteStart], old, new, buf, paramRegExp)
-		buf.WriteString(command[quoteStart:quoteEnd])
+		rewrites = append(rewrites, paramRewrite{ref.start, ref.end, actualStart, buf.Len()})
 
-		prevQuoteEnd = quoteEnd
+		prevEnd = ref.end
 	}
 
-	if buf.Len() > 0 {
-		replaceParameterNameInSubstring(command[prevQuoteEnd:], old, new, buf, paramRegExp)
+	buf.WriteString(command[prevEnd:])
 
-		return buf.String()
+	for i, p := range params {
+		if !used[i] && !isPositional(p) {
+			panic(fmt.Errorf("parameter '%s' is not used in the command", p.name))
+		}
 	}
 
-	replaceParameterNameInSubstring(command, old, new, buf, paramRegExp)
+	return buf.String(), rewrites
+}
 
-	return buf.String()
+// paramRewrite records the replacement of a parameter reference by
+// rewriteCommand.
+type paramRewrite struct {
+	start, end             int // Byte offsets of the reference in the command
+	actualStart, actualEnd int // Byte offsets of the replacement in the actual command
 }
 
-func adjustCommand(command string, params []*Parameter) string {
-	for i, p := range params {
-		var cast string
-		if p.customTypeName != "" {
-			cast = fmt.Sprintf("::%s", p.customTypeName)
+// originalOffset maps a byte offset in the actual command back to the command
+// it was rewritten from. Offsets within a replacement map to the start of the
+// parameter reference.
+func originalOffset(rewrites []paramRewrite, offset int) int {
+	delta := 0
+
+	for _, rw := range rewrites {
+		if offset < rw.actualStart {
+			break
+		}
+		if offset < rw.actualEnd {
+			return rw.start
 		}
-		command = replaceParameterName(command, p.name, fmt.Sprintf("$%d%s", i+1, cast))
+
+		delta = rw.end - rw.actualEnd
 	}
 
-	return command
+	return offset + delta
 }
 
 func newStatement(conn *Conn, command string, params []*Parameter) *Statement {
@@ -96,19 +137,7 @@ func newStatement(conn *Conn, command string, params []*Parameter) *Statement {
 
 	stmt := &Statement{}
 
-	stmt.name2param = make(map[string]*Parameter)
-
-	for _, param := range params {
-		if param == nil {
-			panic("received a nil parameter")
-		}
-		if param.stmt != nil {
-			panic(fmt.Sprintf("parameter '%s' already used in another statement", param.name))
-		}
-		param.stmt = stmt
-
-		stmt.name2param[param.name] = param
-	}
+	stmt.setParams(params)
 
 	stmt.conn = conn
 
@@ -118,13 +147,59 @@ func newStatement(conn *Conn, command string, params []*Parameter) *Statement {
 	stmt.portalName = fmt.Sprint("prtl", conn.nextPortalId)
 	conn.nextPortalId++
 
+	stmt.generation = conn.sessionGeneration
+
 	stmt.command = command
-	stmt.actualCommand = adjustCommand(command, params)
+	stmt.actualCommand, stmt.rewrites = rewriteCommand(command, params, !conn.standardConformingStrings())
+
+	return stmt
+}
+
+// setParams associates params with the Statement, replacing the ones it had
+// before. This allows reusing a cached Statement with new Parameters.
+func (stmt *Statement) setParams(params []*Parameter) {
+	stmt.releaseParams()
+
+	stmt.name2param = make(map[string]*Parameter)
+
+	for _, param := range params {
+		if param == nil {
+			panic(errors.New("received a nil parameter"))
+		}
+		if param.stmt != nil && param.stmt != stmt {
+			panic(fmt.Errorf("parameter '%s' already used in another statement", param.name))
+		}
+		param.stmt = stmt
+
+		stmt.name2param[param.name] = param
+	}
 
 	stmt.params = make([]*Parameter, len(params))
 	copy(stmt.params, params)
 
-	return stmt
+	if stmt.paramTypes != nil {
+		stmt.inferParamTypes()
+	}
+}
+
+// releaseParams detaches the Parameters from the Statement, so they can be
+// used with another one.
+func (stmt *Statement) releaseParams() {
+	for _, param := range stmt.params {
+		if param.stmt == stmt {
+			param.stmt = nil
+		}
+	}
+}
+
+// inferParamTypes gives parameters for $n placeholders, that were created
+// without a type, the type the server inferred and converts their values.
+func (stmt *Statement) inferParamTypes() {
+	for i, p := range stmt.params {
+		if i < len(stmt.paramTypes) && isPositional(p) && p.typ == Custom && p.customTypeName == "" {
+			p.setInferredType(stmt.paramTypes[i])
+		}
+	}
 }
 
 // Conn returns the *Conn this Statement is associated with.
@@ -161,6 +236,40 @@ func (stmt *Statement) Parameters() []*Parameter {
 	return params
 }
 
+// ParamTypes returns the PostgreSQL data types of the parameters of the
+// Statement, as inferred by the server.
+//
+// The result also covers $n placeholders for which no Parameter was passed to
+// *Conn.Prepare. Parameters for those are created automatically and named
+// "$1", "$2" and so on.
+func (stmt *Statement) ParamTypes() []Type {
+	conn := stmt.conn
+
+	if conn.LogLevel >= LogVerbose {
+		defer conn.logExit(conn.logEnter("*Statement.ParamTypes"))
+	}
+
+	types := make([]Type, len(stmt.paramTypes))
+	copy(types, stmt.paramTypes)
+	return types
+}
+
+// Columns returns the names of the fields in the results of the Statement,
+// or an empty slice if it returns no rows.
+func (stmt *Statement) Columns() []string {
+	conn := stmt.conn
+
+	if conn.LogLevel >= LogVerbose {
+		defer conn.logExit(conn.logEnter("*Statement.Columns"))
+	}
+
+	names := make([]string, len(stmt.fields))
+	for i, f := range stmt.fields {
+		names[i] = f.name
+	}
+	return names
+}
+
 // IsClosed returns if the Statement has been closed.
 func (stmt *Statement) IsClosed() bool {
 	conn := stmt.conn
@@ -169,7 +278,12 @@ func (stmt *Statement) IsClosed() bool {
 		defer conn.logExit(conn.logEnter("*Statement.IsClosed"))
 	}
 
-	return stmt.isClosed
+	return stmt.isClosed || stmt.isDiscarded()
+}
+
+// isDiscarded returns if DISCARD ALL has dropped the Statement on the server.
+func (stmt *Statement) isDiscarded() bool {
+	return stmt.generation != stmt.conn.sessionGeneration
 }
 
 func (stmt *Statement) close() {
@@ -179,9 +293,13 @@ func (stmt *Statement) close() {
 		defer conn.logExit(conn.logEnter("*Statement.close"))
 	}
 
-	stmt.conn.writeClose('S', stmt.name)
+	// We don't wait for CloseComplete here, it will be eaten
+	// when reading the response to the next command.
+	conn.writeClose('S', stmt.name)
+	conn.flush()
 
 	stmt.isClosed = true
+	stmt.releaseParams()
 	return
 }
 
@@ -197,9 +315,8 @@ func (stmt *Statement) Close() (err error) {
 // ActualCommand returns the actual command text that is sent to the server.
 //
 // The original command is automatically adjusted if it contains parameters so
-// it complies with what PostgreSQL expects. Refer to the return value of this
-// method to make sense of the position information contained in many error
-// messages.
+// it complies with what PostgreSQL expects. The position of an *Error is
+// mapped back to the original command, see *Error.Position.
 func (stmt *Statement) ActualCommand() string {
 	conn := stmt.conn
 
@@ -248,6 +365,7 @@ func (stmt *Statement) query() (rs *ResultSet) {
 	}
 
 	r := newResultSet(conn)
+	r.stmt = stmt
 
 	conn.state.execute(stmt, r)
 
@@ -269,7 +387,7 @@ func (stmt *Statement) Query() (rs *ResultSet, err error) {
 	return
 }
 
-func (stmt *Statement) execute() (rowsAffected int64) {
+func (stmt *Statement) execute() CommandTag {
 	conn := stmt.conn
 
 	if conn.LogLevel >= LogDebug {
@@ -279,7 +397,7 @@ func (stmt *Statement) execute() (rowsAffected int64) {
 	rs := stmt.query()
 	rs.close()
 
-	return rs.rowsAffected
+	return rs.commandTag
 }
 
 // Execute executes the Statement and returns the number
@@ -289,7 +407,16 @@ func (stmt *Statement) execute() (rowsAffected int64) {
 // Query method instead.
 func (stmt *Statement) Execute() (rowsAffected int64, err error) {
 	err = stmt.conn.withRecover("*Statement.Execute", func() {
-		rowsAffected = stmt.execute()
+		rowsAffected = stmt.execute().RowsAffected
+	})
+
+	return
+}
+
+// ExecuteTag executes the Statement and returns its command tag.
+func (stmt *Statement) ExecuteTag() (tag CommandTag, err error) {
+	err = stmt.conn.withRecover("*Statement.ExecuteTag", func() {
+		tag = stmt.execute()
 	})
 
 	return
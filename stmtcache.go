@@ -0,0 +1,159 @@
+// Copyright 2026 The go-pgsql Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pgsql
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"fmt"
+)
+
+const defaultStatementCacheCapacity = 32
+
+type stmtCacheEntry struct {
+	key  string
+	stmt *Statement
+}
+
+// stmtCache is a LRU cache of the prepared statements *Conn.Query and
+// *Conn.Execute create for commands with parameters.
+type stmtCache struct {
+	capacity int
+	entries  *list.List // Most recently used entry at the front
+	key2elem map[string]*list.Element
+}
+
+func newStmtCache(capacity int) *stmtCache {
+	return &stmtCache{
+		capacity: capacity,
+		entries:  list.New(),
+		key2elem: make(map[string]*list.Element),
+	}
+}
+
+func stmtCacheKey(actualCommand string, params []*Parameter) string {
+	buf := bytes.NewBufferString(actualCommand)
+
+	for _, p := range params {
+		buf.WriteString(fmt.Sprintf("\x00%d", p.typ))
+	}
+
+	return buf.String()
+}
+
+// get returns the cached Statement for key, or nil if there is none.
+func (c *stmtCache) get(key string) *Statement {
+	elem, ok := c.key2elem[key]
+	if !ok {
+		return nil
+	}
+
+	entry := elem.Value.(*stmtCacheEntry)
+	if entry.stmt.isClosed || entry.stmt.isDiscarded() {
+		entry.stmt.releaseParams()
+		c.entries.Remove(elem)
+		delete(c.key2elem, key)
+		return nil
+	}
+
+	c.entries.MoveToFront(elem)
+
+	return entry.stmt
+}
+
+// put adds stmt to the cache. The cache must have room for it.
+func (c *stmtCache) put(key string, stmt *Statement) {
+	c.key2elem[key] = c.entries.PushFront(&stmtCacheEntry{key, stmt})
+}
+
+// evict removes the least recently used statements until the cache holds
+// no more than n entries and returns them. Their Parameters are released, so
+// they can be used again with another statement.
+func (c *stmtCache) evict(n int) (evicted []*Statement) {
+	for c.entries.Len() > n {
+		entry := c.entries.Remove(c.entries.Back()).(*stmtCacheEntry)
+		delete(c.key2elem, entry.key)
+
+		entry.stmt.releaseParams()
+		evicted = append(evicted, entry.stmt)
+	}
+
+	return
+}
+
+// writeCloseEvicted sends Close packets for statements evicted from the
+// statement cache, without flushing.
+func (conn *Conn) writeCloseEvicted(evicted []*Statement) {
+	for _, stmt := range evicted {
+		conn.writeClose('S', stmt.name)
+		stmt.isClosed = true
+	}
+}
+
+// cachedStatement returns a prepared Statement for command and params,
+// reusing a cached one if possible. If the statement cache is disabled, a new
+// Statement is prepared and cached is false, so the caller must close it.
+func (conn *Conn) cachedStatement(command string, params []*Parameter) (stmt *Statement, cached bool) {
+	if conn.LogLevel >= LogDebug {
+		defer conn.logExit(conn.logEnter("*Conn.cachedStatement"))
+	}
+
+	if conn.stmtCache == nil || conn.stmtCache.capacity < 1 {
+		return conn.prepare(command, params...), false
+	}
+
+	key := stmtCacheKey(adjustCommand(command, params, !conn.standardConformingStrings()), params)
+
+	if stmt = conn.stmtCache.get(key); stmt != nil {
+		stmt.setParams(params)
+		return stmt, true
+	}
+
+	// The Close packets for evicted statements are sent together with the
+	// Parse packet of the new statement.
+	conn.writeCloseEvicted(conn.stmtCache.evict(conn.stmtCache.capacity - 1))
+
+	stmt = conn.prepare(command, params...)
+
+	conn.stmtCache.put(key, stmt)
+
+	return stmt, true
+}
+
+// SetStatementCacheCapacity sets the maximum number of prepared statements
+// the connection keeps for reuse by Query and Execute.
+//
+// A capacity of 0 disables the cache. Statements exceeding the new capacity
+// are closed.
+func (conn *Conn) SetStatementCacheCapacity(capacity int) (err error) {
+	return conn.withRecover("*Conn.SetStatementCacheCapacity", func() {
+		if capacity < 0 {
+			panic(errors.New("capacity must be >= 0"))
+		}
+
+		if conn.stmtCache == nil {
+			conn.stmtCache = newStmtCache(capacity)
+			return
+		}
+
+		conn.stmtCache.capacity = capacity
+
+		if evicted := conn.stmtCache.evict(capacity); len(evicted) > 0 {
+			conn.writeCloseEvicted(evicted)
+			conn.flush()
+		}
+	})
+}
+
+// StatementCacheCapacity returns the maximum number of prepared statements
+// the connection keeps for reuse by Query and Execute.
+func (conn *Conn) StatementCacheCapacity() int {
+	if conn.stmtCache == nil {
+		return 0
+	}
+
+	return conn.stmtCache.capacity
+}
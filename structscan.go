@@ -0,0 +1,239 @@
+// Copyright 2026 The go-pgsql Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pgsql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// column2fieldIndexCache maps struct types to their column name to field
+// index mapping.
+var column2fieldIndexCache sync.Map
+
+// snakeCase converts a Go field name like UserID to user_id.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Start a new word at a lower to upper case transition and
+			// before the last upper case letter of an acronym.
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+				b.WriteByte('_')
+			}
+
+			b.WriteRune(unicode.ToLower(r))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// column2fieldIndex returns the column name to field index mapping of the
+// struct type t.
+//
+// The column name of a field is taken from its pgsql tag, if present,
+// otherwise it is the snake_case form of the field name. Fields tagged with
+// `pgsql:"-"` and unexported fields are ignored. The fields of embedded
+// structs are mapped as if they were fields of t, unless they have a tag, but
+// like in Go, fields of t take precedence.
+func column2fieldIndex(t reflect.Type) map[string][]int {
+	if m, ok := column2fieldIndexCache.Load(t); ok {
+		return m.(map[string][]int)
+	}
+
+	m := make(map[string][]int)
+	var embedded []reflect.StructField
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("pgsql")
+
+		if tag == "-" {
+			continue
+		}
+
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, f)
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = snakeCase(f.Name)
+		}
+
+		m[name] = f.Index
+	}
+
+	for _, f := range embedded {
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		for name, index := range column2fieldIndex(ft) {
+			if _, ok := m[name]; !ok {
+				m[name] = append(append([]int(nil), f.Index...), index...)
+			}
+		}
+	}
+
+	column2fieldIndexCache.Store(t, m)
+
+	return m
+}
+
+// fieldByIndex returns the field of the struct v with the specified index,
+// allocating nil embedded struct pointers on the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+// structValue returns the struct dst points to.
+func structValue(dst interface{}) reflect.Value {
+	ptr := reflect.ValueOf(dst)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		panic(errors.New(fmt.Sprintf("destination must be a non-nil pointer to a struct, not %T", dst)))
+	}
+
+	return ptr.Elem()
+}
+
+func (rs *ResultSet) scanStruct(v reflect.Value) {
+	if rs.conn.LogLevel >= LogVerbose {
+		defer rs.conn.logExit(rs.conn.logEnter("*ResultSet.scanStruct"))
+	}
+
+	m := column2fieldIndex(v.Type())
+
+	for i, f := range rs.fields {
+		index, ok := m[f.name]
+		if !ok {
+			if rs.conn.StrictStructScan {
+				panic(errors.New(fmt.Sprintf("column '%s' has no matching field in %s", f.name, v.Type())))
+			}
+			continue
+		}
+
+		rs.scanField(i, fieldByIndex(v, index).Addr().Interface())
+	}
+}
+
+// ScanStruct scans the fields of the current row in the ResultSet into the
+// struct dst points to.
+//
+// Columns are matched to struct fields by name, see the pgsql tag:
+//
+//	type Item struct {
+//		ID        int64   `pgsql:"item_id"` // Column item_id
+//		UnitPrice float64 // Column unit_price
+//		Secret    string  `pgsql:"-"` // Ignored
+//		Audit             // Fields of embedded structs are mapped, too
+//	}
+//
+// Columns without matching field are ignored, unless StrictStructScan of the
+// Conn is true, in which case they cause an error. The supported field types
+// are those supported by Scan.
+func (rs *ResultSet) ScanStruct(dst interface{}) (err error) {
+	err = rs.conn.withRecover("*ResultSet.ScanStruct", func() {
+		rs.scanStruct(structValue(dst))
+	})
+
+	return
+}
+
+// Select executes the query and appends a struct for each row of the
+// ResultSet to the slice dst points to, which must have a struct or
+// struct pointer element type. See *ResultSet.ScanStruct for how columns are
+// mapped to struct fields and Query for the args.
+func (conn *Conn) Select(dst interface{}, command string, args ...interface{}) (err error) {
+	err = conn.withRecover("*Conn.Select", func() {
+		ptr := reflect.ValueOf(dst)
+		if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Slice {
+			panic(errors.New(fmt.Sprintf("destination must be a non-nil pointer to a slice, not %T", dst)))
+		}
+
+		slice := ptr.Elem()
+		elemType := slice.Type().Elem()
+
+		isPtr := elemType.Kind() == reflect.Ptr
+		structType := elemType
+		if isPtr {
+			structType = elemType.Elem()
+		}
+		if structType.Kind() != reflect.Struct {
+			panic(errors.New(fmt.Sprintf("slice elements must be structs or struct pointers, not %s", elemType)))
+		}
+
+		rs := conn.query(command, paramsFromArgs(args)...)
+		defer rs.close()
+
+		for rs.fetchNext() {
+			elem := reflect.New(structType)
+
+			rs.scanStruct(elem.Elem())
+
+			if isPtr {
+				slice = reflect.Append(slice, elem)
+			} else {
+				slice = reflect.Append(slice, elem.Elem())
+			}
+		}
+
+		ptr.Elem().Set(slice)
+	})
+
+	return
+}
+
+// Get executes the query and scans the first row of the ResultSet into
+// the struct dst points to. If a row has been fetched, fetched will be true,
+// otherwise false. See *ResultSet.ScanStruct for how columns are mapped to
+// struct fields and Query for the args.
+func (conn *Conn) Get(dst interface{}, command string, args ...interface{}) (fetched bool, err error) {
+	err = conn.withRecover("*Conn.Get", func() {
+		v := structValue(dst)
+
+		rs := conn.query(command, paramsFromArgs(args)...)
+		defer rs.close()
+
+		if fetched = rs.fetchNext(); fetched {
+			rs.scanStruct(v)
+		}
+	})
+
+	return
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 The go-pgsql Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pgsql
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// quoteLiteral returns s as a string constant, which can be used in a
+// command regardless of the standard_conforming_strings setting.
+func quoteLiteral(s string) string {
+	s = strings.ReplaceAll(s, "'", "''")
+
+	if strings.Contains(s, `\`) {
+		return `E'` + strings.ReplaceAll(s, `\`, `\\`) + `'`
+	}
+
+	return "'" + s + "'"
+}
+
+// PrepareTransaction prepares the transaction for two-phase commit with the
+// specified global identifier, using PREPARE TRANSACTION.
+//
+// The transaction is no longer associated with the connection afterwards, so
+// the Tx is finished. It has to be completed with CommitPrepared or
+// RollbackPrepared, possibly from another connection. PrepareTransaction
+// fails if the transaction has failed, instead of letting the server roll it
+// back.
+func (tx *Tx) PrepareTransaction(gid string) (err error) {
+	return tx.conn.withRecover("*Tx.PrepareTransaction", func() {
+		tx.panicIfFinished()
+
+		if status := tx.conn.transactionStatus; status != InTransaction {
+			panic(fmt.Errorf("%w, can't prepare transaction, status: %s", ErrInvalidState, status))
+		}
+
+		tx.finished = true
+		tx.savepoints = nil
+
+		tx.conn.execute(fmt.Sprintf("PREPARE TRANSACTION %s;", quoteLiteral(gid)))
+	})
+}
+
+// completePrepared commits or rolls back a prepared transaction.
+func (conn *Conn) completePrepared(funcName, command, gid string) error {
+	return conn.withRecover(funcName, func() {
+		if status := conn.transactionStatus; status != NotInTransaction {
+			panic(fmt.Errorf("%w, %s can't be used in a transaction, status: %s", ErrInvalidState, command, status))
+		}
+
+		conn.execute(fmt.Sprintf("%s %s;", command, quoteLiteral(gid)))
+	})
+}
+
+// CommitPrepared commits the transaction prepared for two-phase commit with
+// the specified global identifier. It must not be called in a transaction.
+func (conn *Conn) CommitPrepared(gid string) error {
+	return conn.completePrepared("*Conn.CommitPrepared", "COMMIT PREPARED", gid)
+}
+
+// RollbackPrepared rolls back the transaction prepared for two-phase commit
+// with the specified global identifier. It must not be called in a
+// transaction.
+func (conn *Conn) RollbackPrepared(gid string) error {
+	return conn.completePrepared("*Conn.RollbackPrepared", "ROLLBACK PREPARED", gid)
+}
+
+// PreparedTransaction describes a transaction prepared for two-phase commit,
+// as listed in pg_prepared_xacts.
+type PreparedTransaction struct {
+	TransactionID string    // Numeric transaction id
+	GID           string    // Global identifier
+	Prepared      time.Time // Time the transaction was prepared
+	Owner         string    // Name of the user that executed the transaction
+	Database      string    // Name of the database the transaction was executed in
+}
+
+// PreparedTransactions returns the transactions currently prepared for
+// two-phase commit on the server, e.g. to recover in-doubt transactions after
+// a crash of the transaction coordinator.
+func (conn *Conn) PreparedTransactions() (xacts []PreparedTransaction, err error) {
+	err = conn.withRecover("*Conn.PreparedTransactions", func() {
+		rs := conn.query("SELECT transaction::text, gid, prepared, owner, database FROM pg_prepared_xacts ORDER BY prepared;")
+		defer rs.close()
+
+		for rs.fetchNext() {
+			var xact PreparedTransaction
+
+			rs.scan(&xact.TransactionID, &xact.GID, &xact.Prepared, &xact.Owner, &xact.Database)
+
+			xacts = append(xacts, xact)
+		}
+	})
+
+	return
+}
@@ -0,0 +1,312 @@
+// Copyright 2026 The go-pgsql Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pgsql
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// ErrTxDone is returned by operations on a Tx that has already been committed
+// or rolled back.
+var ErrTxDone = errors.New("pgsql: transaction has already been committed or rolled back")
+
+// ErrSavepointDone is returned by operations on a Savepoint that has been
+// released or was destroyed by rolling back to an enclosing one.
+var ErrSavepointDone = errors.New("pgsql: savepoint has already been released")
+
+// Tx represents a transaction started with *Conn.Begin.
+//
+// A Tx is finished by Commit or Rollback. Rollback on a finished Tx does
+// nothing, so it can be deferred right after Begin:
+//
+//	tx, err := conn.Begin(pgsql.TxOptions{Isolation: pgsql.DefaultIsolation})
+//	if err != nil {
+//		return err
+//	}
+//	defer tx.Rollback()
+//
+//	if _, err := tx.Execute("UPDATE table1 SET x = x + 1;"); err != nil {
+//		return err
+//	}
+//
+//	return tx.Commit()
+type Tx struct {
+	conn       *Conn
+	finished   bool
+	savepoints []*Savepoint // Active savepoints, innermost last
+}
+
+// Begin starts a new transaction with the characteristics specified by opts.
+// It fails if a transaction is already in progress.
+func (conn *Conn) Begin(opts TxOptions) (tx *Tx, err error) {
+	err = conn.withRecover("*Conn.Begin", func() {
+		if conn.transactionStatus != NotInTransaction {
+			panic(fmt.Errorf("%w, transaction already in progress", ErrInvalidState))
+		}
+
+		conn.execute(opts.beginCommand())
+
+		tx = &Tx{conn: conn}
+	})
+
+	return
+}
+
+// Conn returns the *Conn this Tx is associated with.
+func (tx *Tx) Conn() *Conn {
+	return tx.conn
+}
+
+func (tx *Tx) panicIfFinished() {
+	if tx.finished {
+		panic(ErrTxDone)
+	}
+}
+
+// Commit commits the transaction.
+//
+// If the transaction has failed before, the server rolls it back instead and
+// Commit returns an error.
+func (tx *Tx) Commit() (err error) {
+	return tx.conn.withRecover("*Tx.Commit", func() {
+		tx.panicIfFinished()
+
+		tx.finished = true
+		tx.savepoints = nil
+
+		if tag := tx.conn.execute("COMMIT;"); tag.Command == "ROLLBACK" {
+			panic(fmt.Errorf("%w, the transaction has been rolled back", ErrTxFailed))
+		}
+	})
+}
+
+// Rollback rolls back the transaction. If the Tx has already been committed
+// or rolled back, Rollback does nothing.
+func (tx *Tx) Rollback() (err error) {
+	if tx.finished {
+		return nil
+	}
+
+	return tx.conn.withRecover("*Tx.Rollback", func() {
+		tx.finished = true
+		tx.savepoints = nil
+
+		tx.conn.execute("ROLLBACK;")
+	})
+}
+
+// Execute sends a SQL command to the server as part of the transaction, see
+// *Conn.Execute.
+func (tx *Tx) Execute(command string, args ...interface{}) (rowsAffected int64, err error) {
+	err = tx.conn.withRecover("*Tx.Execute", func() {
+		tx.panicIfFinished()
+
+		rowsAffected = tx.conn.execute(command, paramsFromArgs(args)...).RowsAffected
+	})
+
+	return
+}
+
+// Prepare returns a new prepared Statement, see *Conn.Prepare.
+func (tx *Tx) Prepare(command string, args ...interface{}) (stmt *Statement, err error) {
+	err = tx.conn.withRecover("*Tx.Prepare", func() {
+		tx.panicIfFinished()
+
+		stmt = tx.conn.prepare(command, paramsFromArgs(args)...)
+	})
+
+	return
+}
+
+// Query sends a SQL query to the server as part of the transaction, see
+// *Conn.Query.
+func (tx *Tx) Query(command string, args ...interface{}) (rs *ResultSet, err error) {
+	err = tx.conn.withRecover("*Tx.Query", func() {
+		tx.panicIfFinished()
+
+		rs = tx.conn.query(command, paramsFromArgs(args)...)
+	})
+
+	return
+}
+
+// Savepoint represents a savepoint within a Tx.
+type Savepoint struct {
+	tx   *Tx
+	name string
+}
+
+// Savepoint creates a new savepoint within the transaction. Savepoints
+// created while another one is active are nested inside it.
+func (tx *Tx) Savepoint() (sp *Savepoint, err error) {
+	err = tx.conn.withRecover("*Tx.Savepoint", func() {
+		tx.panicIfFinished()
+
+		name := fmt.Sprintf("sp%d", tx.conn.nextSavepointId)
+		tx.conn.nextSavepointId++
+
+		tx.conn.execute(fmt.Sprintf("SAVEPOINT %s;", name))
+
+		sp = &Savepoint{tx: tx, name: name}
+		tx.savepoints = append(tx.savepoints, sp)
+	})
+
+	return
+}
+
+// index returns the position of sp in the active savepoints of its Tx and
+// panics, if it isn't active anymore.
+func (sp *Savepoint) index() int {
+	sp.tx.panicIfFinished()
+
+	for i, active := range sp.tx.savepoints {
+		if active == sp {
+			return i
+		}
+	}
+
+	panic(ErrSavepointDone)
+}
+
+// Release releases the savepoint, keeping the effects of the commands
+// executed after it was created. Nested savepoints are released, too.
+func (sp *Savepoint) Release() (err error) {
+	return sp.tx.conn.withRecover("*Savepoint.Release", func() {
+		i := sp.index()
+
+		sp.tx.conn.execute(fmt.Sprintf("RELEASE SAVEPOINT %s;", sp.name))
+
+		sp.tx.savepoints = sp.tx.savepoints[:i]
+	})
+}
+
+// RollbackTo rolls back the commands executed after the savepoint was
+// created. This also recovers a failed transaction. The savepoint stays
+// active, while nested savepoints are destroyed.
+func (sp *Savepoint) RollbackTo() (err error) {
+	return sp.tx.conn.withRecover("*Savepoint.RollbackTo", func() {
+		i := sp.index()
+
+		sp.tx.conn.execute(fmt.Sprintf("ROLLBACK TO SAVEPOINT %s;", sp.name))
+
+		sp.tx.savepoints = sp.tx.savepoints[:i+1]
+	})
+}
+
+// RetryPolicy controls how often and when RunInTransaction retries a
+// transaction.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the transaction is run,
+	// values < 1 are treated as 1.
+	MaxAttempts int
+
+	// Backoff returns how long to wait before the next attempt, after the
+	// specified attempt has failed. If Backoff is nil, there is no delay.
+	Backoff func(attempt int) time.Duration
+}
+
+// DefaultRetryPolicy runs a transaction up to 5 times, with exponentially
+// growing delays of up to one second.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	Backoff:     ExponentialBackoff(10*time.Millisecond, time.Second),
+}
+
+// ExponentialBackoff returns a RetryPolicy.Backoff function, which doubles
+// the delay after each attempt, starting with base, but not exceeding
+// maxDelay. A random jitter of up to half the delay is subtracted, so
+// concurrent transactions that failed because of each other don't collide
+// again.
+func ExponentialBackoff(base, maxDelay time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := min(base, maxDelay)
+
+		// Doubling stops at maxDelay, so it can't overflow.
+		for i := 1; i < attempt; i++ {
+			if delay > maxDelay/2 {
+				delay = maxDelay
+				break
+			}
+			delay *= 2
+		}
+
+		if delay <= 0 {
+			return 0
+		}
+
+		return delay - time.Duration(rand.Int64N(int64(delay)/2+1))
+	}
+}
+
+// TxRetryError is returned by RunInTransaction, if the transaction could not
+// be completed. Err is the error of the last attempt.
+type TxRetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *TxRetryError) Error() string {
+	return fmt.Sprintf("pgsql: transaction failed after %d attempt(s): %v", e.Attempts, e.Err)
+}
+
+func (e *TxRetryError) Unwrap() error {
+	return e.Err
+}
+
+// isRetryable returns if err is a serialization failure or a deadlock, so
+// running the transaction again may succeed.
+func isRetryable(err error) bool {
+	return IsSerializationFailure(err) || IsDeadlockDetected(err)
+}
+
+func (conn *Conn) runInTransactionOnce(opts TxOptions, f func(tx *Tx) error) error {
+	tx, err := conn.Begin(opts)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := f(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RunInTransaction runs f in a new transaction, which is committed if f
+// returns nil and rolled back otherwise.
+//
+// If the transaction fails with a serialization failure (SQLSTATE 40001) or
+// a deadlock (40P01), it is rolled back and f is run again in a new
+// transaction, as specified by policy. As f may be run several times, it
+// should have no side effects besides those on the database. If the
+// transaction could not be completed, a *TxRetryError is returned.
+func (conn *Conn) RunInTransaction(opts TxOptions, policy RetryPolicy, f func(tx *Tx) error) error {
+	if conn.LogLevel >= LogDebug {
+		defer conn.logExit(conn.logEnter("*Conn.RunInTransaction"))
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := conn.runInTransactionOnce(opts, f)
+		if err == nil {
+			return nil
+		}
+
+		if !isRetryable(err) || attempt >= policy.MaxAttempts {
+			return &TxRetryError{Attempts: attempt, Err: err}
+		}
+
+		if conn.LogLevel >= LogWarning {
+			conn.logf(LogWarning, "transaction attempt %d failed, retrying: %v", attempt, err)
+		}
+
+		if policy.Backoff != nil {
+			time.Sleep(policy.Backoff(attempt))
+		}
+	}
+}
@@ -20,6 +20,7 @@ const (
 	_XIDOID              = 28
 	_CIDOID              = 29
 	_OIDVECTOROID        = 30
+	_JSONOID             = 114
 	_XMLOID              = 142
 	_POINTOID            = 600
 	_LSEGOID             = 601
@@ -51,6 +52,7 @@ const (
 	_TIMESTAMPTZOID      = 1184
 	_INTERVALOID         = 1186
 	_TIMETZOID           = 1266
+	_UUIDOID             = 2950
 	_BITOID              = 1560
 	_VARBITOID           = 1562
 	_NUMERICOID          = 1700
@@ -66,6 +68,7 @@ const (
 	_TSQUERYOID          = 3615
 	_REGCONFIGOID        = 3734
 	_REGDICTIONARYOID    = 3769
+	_JSONBOID            = 3802
 	_RECORDOID           = 2249
 	_RECORDARRAYOID      = 2287
 	_CSTRINGOID          = 2275
@@ -81,6 +84,68 @@ const (
 	_ANYENUMOID          = 3500
 )
 
+var typeOID2Name = map[int32]string{
+	_BOOLOID:        "BOOL",
+	_BYTEAOID:       "BYTEA",
+	_CHAROID:        "CHAR",
+	_NAMEOID:        "NAME",
+	_INT8OID:        "INT8",
+	_INT2OID:        "INT2",
+	_INT4OID:        "INT4",
+	_REGPROCOID:     "REGPROC",
+	_TEXTOID:        "TEXT",
+	_OIDOID:         "OID",
+	_TIDOID:         "TID",
+	_XIDOID:         "XID",
+	_CIDOID:         "CID",
+	_JSONOID:        "JSON",
+	_XMLOID:         "XML",
+	_POINTOID:       "POINT",
+	_LSEGOID:        "LSEG",
+	_PATHOID:        "PATH",
+	_BOXOID:         "BOX",
+	_POLYGONOID:     "POLYGON",
+	_LINEOID:        "LINE",
+	_FLOAT4OID:      "FLOAT4",
+	_FLOAT8OID:      "FLOAT8",
+	_UNKNOWNOID:     "UNKNOWN",
+	_CIRCLEOID:      "CIRCLE",
+	_CASHOID:        "MONEY",
+	_MACADDROID:     "MACADDR",
+	_INETOID:        "INET",
+	_CIDROID:        "CIDR",
+	_INT4ARRAYOID:   "_INT4",
+	_TEXTARRAYOID:   "_TEXT",
+	_FLOAT4ARRAYOID: "_FLOAT4",
+	_BPCHAROID:      "BPCHAR",
+	_VARCHAROID:     "VARCHAR",
+	_DATEOID:        "DATE",
+	_TIMEOID:        "TIME",
+	_TIMESTAMPOID:   "TIMESTAMP",
+	_TIMESTAMPTZOID: "TIMESTAMPTZ",
+	_INTERVALOID:    "INTERVAL",
+	_TIMETZOID:      "TIMETZ",
+	_BITOID:         "BIT",
+	_VARBITOID:      "VARBIT",
+	_NUMERICOID:     "NUMERIC",
+	_REFCURSOROID:   "REFCURSOR",
+	_REGCLASSOID:    "REGCLASS",
+	_REGTYPEOID:     "REGTYPE",
+	_UUIDOID:        "UUID",
+	_TSVECTOROID:    "TSVECTOR",
+	_TSQUERYOID:     "TSQUERY",
+	_RECORDOID:      "RECORD",
+	_VOIDOID:        "VOID",
+	_JSONBOID:       "JSONB",
+}
+
+// typeName returns the name of the built-in type with the specified OID, as
+// found in pg_type, in upper case. For other types, an empty string is
+// returned.
+func typeName(typeOID int32) string {
+	return typeOID2Name[typeOID]
+}
+
 // Type represents the PostgreSQL data type of fields and parameters.
 type Type int32
 
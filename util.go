@@ -4,6 +4,10 @@
 
 package pgsql
 
+import (
+	"errors"
+)
+
 func panicIfErr(err error) {
 	if err != nil {
 		panic(err)
@@ -11,5 +15,5 @@ func panicIfErr(err error) {
 }
 
 func panicNotImplemented() {
-	panic("not implemented")
+	panic(errors.New("not implemented"))
 }